@@ -0,0 +1,199 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	types2 "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"godeploy/shared"
+	"time"
+)
+
+//Implementation of shared.Client for AWS Lambda
+type Client struct{}
+
+func (Client) CreateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d, true)
+}
+
+func (Client) UpdateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d, false)
+}
+
+//Creates or updates the function, creating an existing function updates it instead
+func deploy(cfg shared.Config, d shared.Deployment, create bool) shared.DeploymentResult {
+	start := time.Now()
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	result := shared.NewDeploymentResult(d)
+
+	awsConfig, d, role, err := prepareDeployment(cfg, d)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	//Functions are identified by their ARN
+	if create {
+		result.Function, result.Action, err = createFunction(awsConfig, d, role, start)
+	} else {
+		result.Action = shared.ActionUpdate
+		result.Function, err = updateFunction(awsConfig, d, role, start)
+	}
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func (Client) ListFunctions(cfg shared.Config) ([]shared.Function, error) {
+	lambdaClient := lambda.NewFromConfig(SetupConfig(cfg.Region, cfg.Credentials))
+
+	var f []shared.Function
+	paginator := lambda.NewListFunctionsPaginator(lambdaClient, &lambda.ListFunctionsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("unable to list deployed functions in region %v, Error: %v", cfg.Region, err)
+		}
+
+		f = append(f, shared.Map(page.Functions, func(c types.FunctionConfiguration) shared.Function {
+			return mapFunctionConfiguration(c, cfg.Region)
+		})...)
+	}
+	return f, nil
+}
+
+func (Client) GetFunction(cfg shared.Config, name string) (*shared.Function, error) {
+	lambdaClient := lambda.NewFromConfig(SetupConfig(cfg.Region, cfg.Credentials))
+
+	output, err := lambdaClient.GetFunction(context.Background(), &lambda.GetFunctionInput{FunctionName: &name})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get function %v in region %v, Error: %v", name, cfg.Region, err)
+	}
+
+	f := mapFunctionConfiguration(*output.Configuration, cfg.Region)
+	if output.Concurrency != nil && output.Concurrency.ReservedConcurrentExecutions != nil {
+		f.ReservedConcurrency = *output.Concurrency.ReservedConcurrentExecutions
+	}
+	f.MinInstances, err = getProvisionedConcurrency(lambdaClient, name)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (Client) DesiredFunction(d shared.Deployment) shared.Function {
+	return shared.Function{
+		Name:                d.Name,
+		Provider:            shared.ProviderAWS,
+		Region:              d.Region,
+		Runtime:             d.Runtime,
+		Handler:             getHandler(d),
+		MemorySize:          d.MemorySize,
+		Timeout:             d.Timeout,
+		Environment:         d.Environment,
+		MinInstances:        d.MinInstances,
+		ReservedConcurrency: d.ReservedConcurrency,
+		//Lambda reports the base64 encoded SHA-256 hash of the deployment package
+		CodeHash: shared.FileHash(d.Archive, sha256.New()),
+	}
+}
+
+func (Client) InvokeFunction(cfg shared.Config, name string, payload []byte) shared.Invocation {
+	lambdaClient := lambda.NewFromConfig(SetupConfig(cfg.Region, cfg.Credentials))
+
+	output, err := lambdaClient.Invoke(context.Background(), &lambda.InvokeInput{
+		FunctionName:   &name,
+		InvocationType: types.InvocationTypeRequestResponse,
+		Payload:        payload,
+	})
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to invoke function %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+
+	invocation := shared.Invocation{
+		Status: fmt.Sprint(output.StatusCode),
+		Body:   string(output.Payload),
+	}
+	if output.FunctionError != nil {
+		invocation.Error = *output.FunctionError
+	}
+	return invocation
+}
+
+func (Client) GetLogs(cfg shared.Config, name string, since time.Time) ([]shared.LogEntry, error) {
+	logsClient := cloudwatchlogs.NewFromConfig(SetupConfig(cfg.Region, cfg.Credentials))
+	logGroupName := fmt.Sprintf("/aws/lambda/%v", name)
+	startTime := since.UnixMilli()
+
+	var entries []shared.LogEntry
+	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(logsClient, &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: &logGroupName,
+		StartTime:    &startTime,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		//The log group is only created with the first invocation of the function
+		var notFound *types2.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to get logs of function %v in region %v, Error: %v", name, cfg.Region, err)
+		}
+
+		for _, event := range page.Events {
+			if event.Timestamp == nil || event.Message == nil {
+				continue
+			}
+			entries = append(entries, shared.LogEntry{
+				Timestamp: time.UnixMilli(*event.Timestamp),
+				Provider:  shared.ProviderAWS,
+				Region:    cfg.Region,
+				Message:   *event.Message,
+			})
+		}
+	}
+	return entries, nil
+}
+
+func mapFunctionConfiguration(c types.FunctionConfiguration, region string) shared.Function {
+	f := shared.Function{
+		Provider: shared.ProviderAWS,
+		Region:   region,
+		Runtime:  string(c.Runtime),
+	}
+	if c.FunctionName != nil {
+		f.Name = *c.FunctionName
+	}
+	if c.MemorySize != nil {
+		f.MemorySize = *c.MemorySize
+	}
+	if c.Timeout != nil {
+		f.Timeout = *c.Timeout
+	}
+	if c.Handler != nil {
+		f.Handler = *c.Handler
+	}
+	if c.CodeSha256 != nil {
+		f.CodeHash = *c.CodeSha256
+	}
+	f.Environment = make(map[string]string)
+	if c.Environment != nil {
+		f.Environment = c.Environment.Variables
+	}
+	if c.State != "" {
+		f.State = fmt.Sprintf("%v/%v", c.State, c.LastUpdateStatus)
+	}
+	f.Failed = c.State == types.StateFailed || c.LastUpdateStatus == types.LastUpdateStatusFailed
+	return f
+}
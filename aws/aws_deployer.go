@@ -3,6 +3,7 @@ package aws
 import (
 	"cloud.google.com/go/storage"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -15,86 +16,133 @@ import (
 	"github.com/spf13/viper"
 	"godeploy/shared"
 	"google.golang.org/api/option"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 )
 
-//Map that stores the already created buckets
+//Map that stores the already created buckets, targets of the same region are deployed concurrently
 var bucketExistsMap = make(map[string]string)
+var bucketExistsMutex sync.Mutex
 
-var credHolder shared.CredentialsHolder
+var invalidMetadataCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
 
-func Deploy(waitGroup *sync.WaitGroup, d shared.Deployment, credentialsHolder shared.CredentialsHolder) {
-	defer waitGroup.Done()
-	credHolder = credentialsHolder
-	cfg := SetupConfig(d.Region, credHolder)
-	lambdaClient := lambda.NewFromConfig(cfg)
-	s3Client := s3.NewFromConfig(cfg)
+//Uploads the archive to the deployment bucket of the region, the deployment references the uploaded archive by bucket and key
+func (Client) UploadArchive(c shared.Config, d shared.Deployment) (shared.Deployment, error) {
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	cfg, err := LoadConfig(d.Region, c.Credentials)
+	if err != nil {
+		return d, err
+	}
 
 	start := time.Now()
-	bucketName, objectKey := uploadArchive(s3Client, d.Region, d.Name, d.Archive)
+	bucketName, objectKey, err := uploadArchive(c.Credentials, s3.NewFromConfig(cfg), d.Region, shared.ArchiveKey(d.Name, d.Version), d.Archive, objectMetadata(d.Tags))
+	if err != nil {
+		return d, err
+	}
 	elapsed := time.Since(start)
 	
 	shared.Log(shared.ProviderAWS, fmt.Sprintf("Name: %v, Region: %v, upload took %s", d.Name, d.Region, elapsed))
 
 	d.Bucket = bucketName
 	d.Key = objectKey
+	return d, nil
+}
 
-	r := getRoleARN(iam.NewFromConfig(cfg))
-	createFunction(lambdaClient, d, r)
+//Uploads the archive if it has not been uploaded yet and returns everything needed to create or update the function,
+//the SDK config is built from the credentials of the target, so targets with different credentials can be deployed concurrently
+func prepareDeployment(c shared.Config, d shared.Deployment) (aws.Config, shared.Deployment, string, error) {
+	if d.Bucket == "" {
+		uploaded, err := Client{}.UploadArchive(c, d)
+		if err != nil {
+			return aws.Config{}, d, "", err
+		}
+		d = uploaded
+	}
+	cfg, err := LoadConfig(d.Region, c.Credentials)
+	if err != nil {
+		return cfg, d, "", err
+	}
+
+	r, err := getRoleARN(iam.NewFromConfig(cfg))
+	return cfg, d, r, err
 }
 
-func uploadArchive(client *s3.Client, region string, objectKey string, archiveURL string) (string, string) {
+func uploadArchive(c shared.CredentialsHolder, client *s3.Client, region string, objectKey string, archiveURL string, metadata map[string]string) (string, string, error) {
 	//Check if bucket exists for a specific region
-	bucketName := bucketExists(client, region)
+	bucketName, err := bucketExists(client, region)
+	if err != nil {
+		return "", "", err
+	}
 	if bucketName == "" {
-		bucketName = createBucket(client, region)
+		if bucketName, err = createBucket(client, region); err != nil {
+			return "", "", err
+		}
 	} else {
 		shared.Log(shared.ProviderAWS, fmt.Sprintf("deployment bucket for region %v already exists", region))
 	}
 
 	if shared.IsAWSObjectURI(archiveURL) {
-		return archiveURL, " "
+		return copyWithinAWS(archiveURL, bucketName, objectKey, client, metadata)
 	} else if shared.IsGoogleObjectURI(archiveURL) {
-		return copyFromGoogleToAWS(archiveURL, bucketName, client)
+		return copyFromGoogleToAWS(c, archiveURL, bucketName, objectKey, client, metadata)
 	}
 
 	f, err := os.Open(archiveURL)
-	shared.CheckErr(err, fmt.Sprintf("os.Open: %v, Error: %v", archiveURL, err))
+	if err != nil {
+		return "", "", fmt.Errorf("os.Open: %v, Error: %v", archiveURL, err)
+	}
 	defer f.Close()
 
-	_, err = client.PutObject(context.Background(), &s3.PutObjectInput{Bucket: &bucketName, Key: &objectKey, Body: f})
-	shared.CheckErr(err, fmt.Sprintf("unable to upload archive to bucket on AWS, Error: %v", err))
+	_, err = client.PutObject(context.Background(), &s3.PutObjectInput{Bucket: &bucketName, Key: &objectKey, Body: f, Metadata: metadata})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to upload archive to bucket on AWS, Error: %v", err)
+	}
 
-	return bucketName, objectKey
+	return bucketName, objectKey, nil
 }
 
-func createBucket(storageClient *s3.Client, region string) string {
+func createBucket(storageClient *s3.Client, region string) (string, error) {
 	shared.Log(shared.ProviderAWS, fmt.Sprintf("Create bucket for region %v", region))
 
-	bucketName := shared.ArchiveBucketName + "-" + region
+	bucketName := archiveBucketName(region)
 	bucketInput := &s3.CreateBucketInput{Bucket: &bucketName}
 	//Not default locations (other than "us-east-1") need an explicit LocationConstraint set
 	if region != shared.DefaultAWSRegion {
 		bucketInput.CreateBucketConfiguration = &types2.CreateBucketConfiguration{LocationConstraint: types2.BucketLocationConstraint(region)}
 	}
 	_, err := storageClient.CreateBucket(context.Background(), bucketInput)
-	shared.CheckErr(err, fmt.Sprintf("unable to create bucket on AWS for region %v, Error: %v", region, err))
+	if err != nil {
+		return "", fmt.Errorf("unable to create bucket on AWS for region %v, Error: %v", region, err)
+	}
 
-	return bucketName
+	return bucketName, nil
 }
 
-//Check if the bucket containing the deployments already exists for the specified region
-func bucketExists(client *s3.Client, region string) string {
+//Name of the bucket containing the deployments of a region
+func archiveBucketName(region string) string {
+	return shared.ArchiveBucketName + "-" + region
+}
+
+//Check if the bucket containing the deployments already exists for the specified region.
+//Only the bucket created by GoDeploy matches, as archives are deleted from it
+func bucketExists(client *s3.Client, region string) (string, error) {
+	bucketExistsMutex.Lock()
+	defer bucketExistsMutex.Unlock()
 	if bucketExistsMap[region] != "" {
 		shared.Log(shared.ProviderAWS, fmt.Sprintf("Already checked if bucket exists for region %v", region))
-		return bucketExistsMap[region]
+		return bucketExistsMap[region], nil
 	}
 
 	output, err := client.ListBuckets(context.Background(), &s3.ListBucketsInput{})
-	shared.CheckErr(err, fmt.Sprintf("unable to list buckets on AWS, error msg: %v", err))
+	if err != nil {
+		return "", fmt.Errorf("unable to list buckets on AWS, error msg: %v", err)
+	}
 
 	var bucketNames []string
 	for _, b := range output.Buckets {
@@ -103,31 +151,35 @@ func bucketExists(client *s3.Client, region string) string {
 		}
 	}
 	for _, bName := range bucketNames {
-		if strings.Contains(bName, region) {
+		if bName == archiveBucketName(region) {
 			bucketExistsMap[region] = bName
-			return bName
+			return bName, nil
 		}
 	}
-	return ""
+	return "", nil
 }
 
 func SetupConfig(region string, c shared.CredentialsHolder) aws.Config {
-	staticCredentialsProvider := credentials.StaticCredentialsProvider{Value: *c.AwsCredentials}
-	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region), config.WithCredentialsProvider(staticCredentialsProvider))
-	shared.CheckErr(err, fmt.Sprintf("unable to load AWS SDK config, Error: %v", err))
+	cfg, err := LoadConfig(region, c)
+	shared.CheckErr(err, err)
 
 	return cfg
 }
 
-func createFunction(client *lambda.Client, d shared.Deployment, role string) string {
+func LoadConfig(region string, c shared.CredentialsHolder) (aws.Config, error) {
+	staticCredentialsProvider := credentials.StaticCredentialsProvider{Value: *c.AwsCredentials}
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region), config.WithCredentialsProvider(staticCredentialsProvider))
+	if err != nil {
+		return cfg, fmt.Errorf("unable to load AWS SDK config, Error: %v", err)
+	}
+	return cfg, nil
+}
+
+func createFunction(cfg aws.Config, d shared.Deployment, role string, start time.Time) (string, shared.DeploymentAction, error) {
+	client := lambda.NewFromConfig(cfg)
 	shared.Log(shared.ProviderAWS, fmt.Sprintf("Started creating function %v in region %v with %v MB memory", d.Name, d.Region, d.MemorySize))
 	
-	start := time.Now()
-	handler := d.HandlerFile
-
-	if strings.Contains(d.Runtime, "python") {
-		handler = fmt.Sprintf("%v.%v", d.HandlerFile, d.HandlerFunction)
-	}
+	handler := getHandler(d)
 
 	params := &lambda.CreateFunctionInput{
 		Code:         &types.FunctionCode{S3Bucket: &d.Bucket, S3Key: &d.Key},
@@ -138,6 +190,11 @@ func createFunction(client *lambda.Client, d shared.Deployment, role string) str
 		MemorySize:   &d.MemorySize,
 		Runtime:      types.Runtime(d.Runtime),
 		PackageType:  types.PackageTypeZip,
+		Environment:  &types.Environment{Variables: d.Environment},
+		Tags:         d.Tags,
+	}
+	if len(d.Network.Subnets) > 0 {
+		params.VpcConfig = getVpcConfig(d)
 	}
 
 	createdFunction, err := client.CreateFunction(context.Background(), params)
@@ -145,29 +202,47 @@ func createFunction(client *lambda.Client, d shared.Deployment, role string) str
 	if err != nil && strings.Contains(err.Error(), "https response error StatusCode: 409") && 
 		strings.Contains(err.Error(), "ResourceConflictException: Function already exist") {
 		shared.Log(shared.ProviderAWS, fmt.Sprintf("Function %v in region %v already exists. Updating function...", d.Name, d.Region))
-		return updateFunction(client, d, role, start)
-	} else {
-		shared.CheckErr(err, fmt.Sprintf("Error: unable to create function %v, Error %v", *params.FunctionName, err))
+		functionARN, err := updateFunction(cfg, d, role, start)
+		return functionARN, shared.ActionUpdate, err
+	} else if err != nil {
+		return "", shared.ActionCreate, fmt.Errorf("unable to create function %v, Error %v", *params.FunctionName, err)
+	}
+
+	if err = finishDeployment(cfg, client, d, *createdFunction.FunctionArn); err != nil {
+		return *createdFunction.FunctionArn, shared.ActionCreate, err
 	}
 
 	elapsed := time.Since(start)
 	shared.Log(shared.ProviderAWS, fmt.Sprintf("Finished creating function %v in region %v with %v MB memory, took %s", d.Name, d.Region, d.MemorySize, elapsed))
-	return *createdFunction.FunctionArn
+	return *createdFunction.FunctionArn, shared.ActionCreate, nil
 }
 
-func updateFunction(client *lambda.Client, d shared.Deployment, role string, start time.Time) string {
+func updateFunction(cfg aws.Config, d shared.Deployment, role string, start time.Time) (string, error) {
+	client := lambda.NewFromConfig(cfg)
 	shared.Log(shared.ProviderAWS, fmt.Sprintf("Started updating function %v in region %v with %v MB memory", d.Name, d.Region, d.MemorySize))
 
+	handler := getHandler(d)
 	configurationParams := &lambda.UpdateFunctionConfigurationInput{
 		FunctionName: &d.Name,
-		Handler:      &d.HandlerFile,
+		Handler:      &handler,
 		Timeout:      &d.Timeout,
 		MemorySize:   &d.MemorySize,
 		Role:         &role,
 		Runtime:      types.Runtime(d.Runtime),
+		Environment:  &types.Environment{Variables: d.Environment},
+		VpcConfig:    getVpcConfig(d),
 	}
 	updatedFunction, err := client.UpdateFunctionConfiguration(context.Background(), configurationParams)
-	shared.CheckErr(err, fmt.Sprintf("unable to update function configuration, Error: %v", err))
+	if err != nil {
+		return "", fmt.Errorf("unable to update function configuration, Error: %v", err)
+	}
+	functionARN := *updatedFunction.FunctionArn
+
+	//Tags are not part of the configuration, tags removed from the deployment file are kept
+	_, err = client.TagResource(context.Background(), &lambda.TagResourceInput{Resource: updatedFunction.FunctionArn, Tags: d.Tags})
+	if err != nil {
+		return functionARN, fmt.Errorf("unable to tag function %v, Error: %v", d.Name, err)
+	}
 
 	maxRetries := 5
 	retryDelay := 500 * time.Millisecond
@@ -187,55 +262,232 @@ func updateFunction(client *lambda.Client, d shared.Deployment, role string, sta
 		}
     }
 
-	shared.CheckErr(err, fmt.Sprintf("unable to update function code, Error: %v", err))
+	if err != nil {
+		return functionARN, fmt.Errorf("unable to update function code, Error: %v", err)
+	}
+	if err = finishDeployment(cfg, client, d, functionARN); err != nil {
+		return functionARN, err
+	}
+
 	elapsed := time.Since(start)
 	shared.Log(shared.ProviderAWS, fmt.Sprintf("Finished updating function %v in region %v with %v MB memory, took %s", d.Name, d.Region, d.MemorySize, elapsed))
 
-	return *updatedFunction.FunctionArn
+	return functionARN, nil
+}
+
+//Publishes the deployed function and applies the settings that are not part of its configuration
+func finishDeployment(cfg aws.Config, client *lambda.Client, d shared.Deployment, functionARN string) error {
+	if err := publishVersion(client, d); err != nil {
+		return err
+	}
+	if err := setConcurrency(client, d); err != nil {
+		return err
+	}
+	return reconcileTriggers(cfg, client, d, functionARN)
+}
+
+//Publishes the deployed code and configuration as new Lambda version and points the live alias to it,
+//the description of the published version is set to the version of the deployment. Deployments reusing an unchanged version
+//are recorded in an alias named after their version instead
+func publishVersion(client *lambda.Client, d shared.Deployment) error {
+	if err := waitForUpdate(client, d.Name); err != nil {
+		return err
+	}
+
+	published, err := client.PublishVersion(context.Background(), &lambda.PublishVersionInput{FunctionName: &d.Name, Description: &d.Version})
+	if err != nil {
+		return fmt.Errorf("unable to publish version of function %v, Error: %v", d.Name, err)
+	}
+
+	//Lambda returns the existing version with its old description if code and configuration are unchanged
+	if published.Description == nil || *published.Description != d.Version {
+		shared.Log(shared.ProviderAWS, fmt.Sprintf("Function %v in region %v is unchanged, version %v reuses published version %v", d.Name, d.Region, d.Version, *published.Version))
+		if err = setAlias(client, d.Name, shared.AWSVersionAliasPrefix+d.Version, *published.Version); err != nil {
+			return err
+		}
+	}
+
+	if err = setLiveAlias(client, d.Name, *published.Version); err != nil {
+		return err
+	}
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Published version %v (%v) of function %v in region %v", *published.Version, d.Version, d.Name, d.Region))
+	return nil
+}
+
+func setLiveAlias(client *lambda.Client, name string, functionVersion string) error {
+	return setAlias(client, name, shared.AWSLiveAlias, functionVersion)
+}
+
+//Points the alias to the given Lambda version, the alias is created if it doesn't exist
+func setAlias(client *lambda.Client, name string, alias string, functionVersion string) error {
+	_, err := client.UpdateAlias(context.Background(), &lambda.UpdateAliasInput{FunctionName: &name, Name: &alias, FunctionVersion: &functionVersion})
+
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		_, err = client.CreateAlias(context.Background(), &lambda.CreateAliasInput{FunctionName: &name, Name: &alias, FunctionVersion: &functionVersion})
+	}
+	if err != nil {
+		return fmt.Errorf("unable to point alias %v of function %v to version %v, Error: %v", alias, name, functionVersion, err)
+	}
+	return nil
+}
+
+//Returns the VPC configuration of the function, empty lists detach the function from its VPC
+func getVpcConfig(d shared.Deployment) *types.VpcConfig {
+	vpcConfig := &types.VpcConfig{SubnetIds: []string{}, SecurityGroupIds: []string{}}
+	if len(d.Network.Subnets) > 0 {
+		vpcConfig.SubnetIds = d.Network.Subnets
+		vpcConfig.SecurityGroupIds = d.Network.SecurityGroups
+	}
+	return vpcConfig
+}
+
+//Reserves concurrency for the function and provisions concurrency for the live alias, settings not given are removed
+func setConcurrency(client *lambda.Client, d shared.Deployment) error {
+	var err error
+	if d.ReservedConcurrency > 0 {
+		_, err = client.PutFunctionConcurrency(context.Background(), &lambda.PutFunctionConcurrencyInput{FunctionName: &d.Name, ReservedConcurrentExecutions: &d.ReservedConcurrency})
+	} else {
+		_, err = client.DeleteFunctionConcurrency(context.Background(), &lambda.DeleteFunctionConcurrencyInput{FunctionName: &d.Name})
+	}
+	if err != nil {
+		return fmt.Errorf("unable to set reserved concurrency of function %v, Error: %v", d.Name, err)
+	}
+
+	alias := shared.AWSLiveAlias
+	if d.MinInstances > 0 {
+		_, err = client.PutProvisionedConcurrencyConfig(context.Background(), &lambda.PutProvisionedConcurrencyConfigInput{FunctionName: &d.Name, Qualifier: &alias, ProvisionedConcurrentExecutions: &d.MinInstances})
+		if err != nil {
+			return fmt.Errorf("unable to set provisioned concurrency of function %v, Error: %v", d.Name, err)
+		}
+		shared.Log(shared.ProviderAWS, fmt.Sprintf("Provisioned concurrency of %v for function %v in region %v", d.MinInstances, d.Name, d.Region))
+		return nil
+	}
+	provisioned, err := getProvisionedConcurrency(client, d.Name)
+	if err != nil {
+		return err
+	}
+	if provisioned > 0 {
+		_, err = client.DeleteProvisionedConcurrencyConfig(context.Background(), &lambda.DeleteProvisionedConcurrencyConfigInput{FunctionName: &d.Name, Qualifier: &alias})
+		if err != nil {
+			return fmt.Errorf("unable to remove provisioned concurrency of function %v, Error: %v", d.Name, err)
+		}
+	}
+	return nil
+}
+
+//Returns the provisioned concurrency requested for the live alias, or 0 if there is none
+func getProvisionedConcurrency(client *lambda.Client, name string) (int32, error) {
+	alias := shared.AWSLiveAlias
+	output, err := client.GetProvisionedConcurrencyConfig(context.Background(), &lambda.GetProvisionedConcurrencyConfigInput{FunctionName: &name, Qualifier: &alias})
+	var notFound *types.ProvisionedConcurrencyConfigNotFoundException
+	var aliasNotFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) || errors.As(err, &aliasNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to get provisioned concurrency of function %v, Error: %v", name, err)
+	}
+
+	if output.RequestedProvisionedConcurrentExecutions == nil {
+		return 0, nil
+	}
+	return *output.RequestedProvisionedConcurrentExecutions, nil
 }
 
-func getDeployedFunctions(c *lambda.Client) *lambda.ListFunctionsOutput {
-	functions, err := c.ListFunctions(context.Background(), nil)
-	shared.CheckErr(err, fmt.Sprintf("unable to list deployed functions, Error: %v", err))
-	return functions
+//Waits until a previous create or update of the function has finished
+func waitForUpdate(client *lambda.Client, name string) error {
+	err := lambda.NewFunctionActiveWaiter(client).Wait(context.Background(), &lambda.GetFunctionConfigurationInput{FunctionName: &name}, 5*time.Minute)
+	if err != nil {
+		return fmt.Errorf("unable to wait for function %v to become active, Error: %v", name, err)
+	}
+
+	err = lambda.NewFunctionUpdatedWaiter(client).Wait(context.Background(), &lambda.GetFunctionConfigurationInput{FunctionName: &name}, 5*time.Minute)
+	if err != nil {
+		return fmt.Errorf("unable to wait for update of function %v, Error: %v", name, err)
+	}
+	return nil
 }
 
-func getFunctionNames(f lambda.ListFunctionsOutput) []string {
-	functionNameMapper := func(f types.FunctionConfiguration) string { return *f.FunctionName }
-	return shared.Map(f.Functions, functionNameMapper)
+//Python handlers need the file and the function, while other runtimes only need the handler class
+func getHandler(d shared.Deployment) string {
+	if strings.Contains(d.Runtime, "python") {
+		return fmt.Sprintf("%v.%v", d.HandlerFile, d.HandlerFunction)
+	}
+	return d.HandlerFile
 }
 
-func getRoleARN(c *iam.Client) string {
+func getRoleARN(c *iam.Client) (string, error) {
 	role := viper.GetString(shared.AWSRoleKey)
 	r, err := c.GetRole(context.Background(), &iam.GetRoleInput{RoleName: &role})
-	shared.CheckErr(err, fmt.Sprintf("unable to get role ARN for role name {%v}, Error: %v", role, err))
-	return *r.Role.Arn
+	if err != nil {
+		return "", fmt.Errorf("unable to get role ARN for role name {%v}, Error: %v", role, err)
+	}
+	return *r.Role.Arn, nil
 }
 
-func copyFromGoogleToAWS(srcURL string, targetBucket string, s3Client *s3.Client) (string, string) {
-	storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(credHolder.GoogleCredentials))
-	shared.CheckErr(err, fmt.Sprintf("unable to create Google storage client, Error: %v", err))
+func copyFromGoogleToAWS(c shared.CredentialsHolder, srcURL string, targetBucket string, targetKey string, s3Client *s3.Client, metadata map[string]string) (string, string, error) {
+	storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(c.GoogleCredentials))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to create Google storage client, Error: %v", err)
+	}
 	defer storageClient.Close()
 
 	bucket, key := shared.ParseStorageObjectURI(srcURL)
 	if bucket == "" && key == "" {
-		fmt.Fprintln(os.Stderr, fmt.Sprintf("Error: unable to parse Google object URI {%v}", srcURL))
-		os.Exit(1)
+		return "", "", fmt.Errorf("unable to parse Google object URI {%v}", srcURL)
 	}
 
 	reader, err := storageClient.Bucket(bucket).Object(key).NewReader(context.Background())
-	shared.CheckErr(err, fmt.Sprintf("unable to read from Google object, Error: %v", err))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read from Google object, Error: %v", err)
+	}
 	defer reader.Close()
 
 	_, err = s3Client.PutObject(context.Background(), &s3.PutObjectInput{
 		Bucket:        &targetBucket,
-		Key:           &key,
+		Key:           &targetKey,
 		Body:          reader,
 		ContentLength: reader.Attrs.Size,
+		Metadata:      metadata,
 	})
-	shared.CheckErr(err, fmt.Sprintf("unable to put object in S3, Error: %v\n", err))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to put object in S3, Error: %v", err)
+	}
 
-	return targetBucket, key
+	return targetBucket, targetKey, nil
+}
+
+//Copies an archive referenced via an S3 URI into the deployment bucket, so every version keeps its own archive
+func copyWithinAWS(srcURL string, targetBucket string, targetKey string, s3Client *s3.Client, metadata map[string]string) (string, string, error) {
+	bucket, key := shared.ParseStorageObjectURI(srcURL)
+	if bucket == "" && key == "" {
+		return "", "", fmt.Errorf("unable to parse S3 object URI {%v}", srcURL)
+	}
+
+	copySource := strings.Join(shared.Map(strings.Split(fmt.Sprintf("%v/%v", bucket, key), "/"), url.PathEscape), "/")
+	_, err := s3Client.CopyObject(context.Background(), &s3.CopyObjectInput{
+		Bucket:     &targetBucket,
+		Key:        &targetKey,
+		CopySource: &copySource,
+		//The metadata of the source object is replaced by the tags of the function
+		Metadata:          metadata,
+		MetadataDirective: types2.MetadataDirectiveReplace,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to copy object %v in S3, Error: %v", srcURL, err)
+	}
+
+	return targetBucket, targetKey, nil
+}
+
+//S3 stores metadata as HTTP headers, so characters that are valid in tags but not in header names are replaced
+func objectMetadata(tags map[string]string) map[string]string {
+	metadata := make(map[string]string)
+	for key, value := range tags {
+		metadata[invalidMetadataCharacters.ReplaceAllString(key, "-")] = value
+	}
+	return metadata
 }
 
 func buildS3URI(bucket string, key string) string {
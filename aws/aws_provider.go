@@ -0,0 +1,53 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+)
+
+func init() {
+	shared.RegisterProvider(Client{})
+}
+
+func (Client) Name() shared.ProviderName {
+	return shared.ProviderAWS
+}
+
+func (Client) Limits() shared.ProviderLimits {
+	return limits
+}
+
+func (Client) DefaultRegion() string {
+	return shared.DefaultAWSRegion
+}
+
+func (Client) DefaultRuntime() string {
+	return "python3.9"
+}
+
+func (Client) CredentialsFile() string {
+	return shared.AWSCredentialsFile
+}
+
+func (Client) CredentialsTemplate() string {
+	return shared.AWSRoleKey + `: "` + shared.DefaultAWSRole + `"
+` + shared.AWSAccessKey + `: "<ACCESS_KEY_ID>"
+` + shared.AWSSecretAccessKey + `: "<SECRET_ACCESS_KEY>"
+` + shared.AWSSessionTokenKey + `: "<SESSION_TOKEN>"
+`
+}
+
+//The keys of the credentials file are read from the configuration, the role is read when the function is deployed
+func (Client) LoadCredentials(file string, credentials *shared.CredentialsHolder) error {
+	credentials.AwsCredentials = &aws.Credentials{
+		AccessKeyID:     viper.GetString(shared.AWSAccessKey),
+		SecretAccessKey: viper.GetString(shared.AWSSecretAccessKey),
+		SessionToken:    viper.GetString(shared.AWSSessionTokenKey),
+	}
+	return nil
+}
+
+func (Client) IsArchiveURI(archive string) bool {
+	return shared.IsAWSObjectURI(archive)
+}
@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"godeploy/shared"
+	"time"
+)
+
+func (Client) DeleteFunction(c shared.Config, d shared.Deployment, removeArchive bool) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionDelete
+
+	cfg, err := LoadConfig(d.Region, c.Credentials)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	if err = deleteFunction(cfg, lambda.NewFromConfig(cfg), d); err != nil {
+		return result.Fail(err, start)
+	}
+	if removeArchive {
+		if err = deleteArchive(s3.NewFromConfig(cfg), d); err != nil {
+			return result.Fail(err, start)
+		}
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func deleteFunction(cfg aws.Config, client *lambda.Client, d shared.Deployment) error {
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Started deleting function %v in region %v", d.Name, d.Region))
+
+	start := time.Now()
+	//Triggers are not deleted together with the function, as they belong to other services
+	if err := removeTriggers(cfg, client, d); err != nil {
+		return err
+	}
+	_, err := client.DeleteFunction(context.Background(), &lambda.DeleteFunctionInput{FunctionName: &d.Name})
+
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		shared.Log(shared.ProviderAWS, fmt.Sprintf("Function %v in region %v does not exist, nothing to delete", d.Name, d.Region))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to delete function %v, Error: %v", d.Name, err)
+	}
+
+	elapsed := time.Since(start)
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Finished deleting function %v in region %v, took %s", d.Name, d.Region, elapsed))
+	return nil
+}
+
+//Deletes all archive versions uploaded by Deploy
+func deleteArchive(client *s3.Client, d shared.Deployment) error {
+	bucketName, err := bucketExists(client, d.Region)
+	if err != nil || bucketName == "" {
+		return err
+	}
+
+	//Archives of local files were stored under the function name before they were versioned
+	objectKeys := []string{d.Name}
+	prefix := shared.ArchiveKey(d.Name, "")
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: &bucketName, Prefix: &prefix})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return fmt.Errorf("unable to list archives of function %v in bucket %v, Error: %v", d.Name, bucketName, err)
+		}
+		for _, object := range page.Contents {
+			objectKeys = append(objectKeys, *object.Key)
+		}
+	}
+
+	for _, objectKey := range objectKeys {
+		key := objectKey
+		if _, err := client.DeleteObject(context.Background(), &s3.DeleteObjectInput{Bucket: &bucketName, Key: &key}); err != nil {
+			return fmt.Errorf("unable to delete archive %v from bucket %v on AWS, Error: %v", key, bucketName, err)
+		}
+	}
+
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Deleted %v archives of function %v from bucket %v", len(objectKeys)-1, d.Name, bucketName))
+	return nil
+}
@@ -0,0 +1,165 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"godeploy/shared"
+	"strings"
+	"time"
+)
+
+//Restores the code and configuration of a previously published version and points the live alias to it
+func (Client) RollbackFunction(c shared.Config, d shared.Deployment, requestedVersion string) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionRollback
+
+	cfg, err := LoadConfig(d.Region, c.Credentials)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	client := lambda.NewFromConfig(cfg)
+
+	published, err := getPublishedVersions(client, d.Name)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	var versions []string
+	for version := range published {
+		versions = append(versions, version)
+	}
+	liveVersion, err := getLiveVersion(client, d.Name, published)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	version, err := shared.RollbackVersion(versions, liveVersion, requestedVersion)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	result.Version = version
+	target := published[version]
+
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Started rolling back function %v in region %v to version %v", d.Name, d.Region, version))
+
+	environment := &types.Environment{Variables: map[string]string{}}
+	if target.Environment != nil {
+		environment.Variables = target.Environment.Variables
+	}
+	_, err = client.UpdateFunctionConfiguration(context.Background(), &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: &d.Name,
+		Handler:      target.Handler,
+		Timeout:      target.Timeout,
+		MemorySize:   target.MemorySize,
+		Role:         target.Role,
+		Runtime:      target.Runtime,
+		Environment:  environment,
+	})
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to restore function configuration, Error: %v", err), start)
+	}
+	if err = waitForUpdate(client, d.Name); err != nil {
+		return result.Fail(err, start)
+	}
+
+	bucketName, err := bucketExists(s3.NewFromConfig(cfg), d.Region)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	objectKey := shared.ArchiveKey(d.Name, version)
+	_, err = client.UpdateFunctionCode(context.Background(), &lambda.UpdateFunctionCodeInput{
+		FunctionName: &d.Name,
+		S3Bucket:     &bucketName,
+		S3Key:        &objectKey,
+	})
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to restore function code from %v, Error: %v", buildS3URI(bucketName, objectKey), err), start)
+	}
+	if err = waitForUpdate(client, d.Name); err != nil {
+		return result.Fail(err, start)
+	}
+	if err = setLiveAlias(client, d.Name, *target.Version); err != nil {
+		return result.Fail(err, start)
+	}
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Finished rolling back function %v in region %v to version %v, took %s", d.Name, d.Region, version, result.Duration))
+	return result
+}
+
+//Returns the versions published by Deploy, mapped by the version of the deployment stored in their description
+func getPublishedVersions(client *lambda.Client, name string) (map[string]types.FunctionConfiguration, error) {
+	versions := make(map[string]types.FunctionConfiguration)
+
+	paginator := lambda.NewListVersionsByFunctionPaginator(client, &lambda.ListVersionsByFunctionInput{FunctionName: &name})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("unable to list versions of function %v, Error: %v", name, err)
+		}
+
+		for _, version := range page.Versions {
+			if version.Version == nil || *version.Version == "$LATEST" || version.Description == nil || *version.Description == "" {
+				continue
+			}
+			versions[*version.Description] = version
+		}
+	}
+
+	//Deployments that reused a published version are recorded in aliases
+	aliases := lambda.NewListAliasesPaginator(client, &lambda.ListAliasesInput{FunctionName: &name})
+	for aliases.HasMorePages() {
+		page, err := aliases.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("unable to list aliases of function %v, Error: %v", name, err)
+		}
+
+		for _, alias := range page.Aliases {
+			if alias.Name == nil || alias.FunctionVersion == nil || !strings.HasPrefix(*alias.Name, shared.AWSVersionAliasPrefix) {
+				continue
+			}
+			for _, version := range versions {
+				if *version.Version == *alias.FunctionVersion {
+					versions[strings.TrimPrefix(*alias.Name, shared.AWSVersionAliasPrefix)] = version
+					break
+				}
+			}
+		}
+	}
+	return versions, nil
+}
+
+//Returns the deployment version the live alias points to, or the latest version if there is no alias
+func getLiveVersion(client *lambda.Client, name string, published map[string]types.FunctionConfiguration) (string, error) {
+	alias := shared.AWSLiveAlias
+	output, err := client.GetAlias(context.Background(), &lambda.GetAliasInput{FunctionName: &name, Name: &alias})
+
+	var notFound *types.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		if err != nil {
+			return "", fmt.Errorf("unable to get alias %v of function %v, Error: %v", alias, name, err)
+		}
+		//Several deployment versions can share a Lambda version, the latest of them is live
+		live := ""
+		for version, configuration := range published {
+			if *configuration.Version == *output.FunctionVersion && version > live {
+				live = version
+			}
+		}
+		if live != "" {
+			return live, nil
+		}
+	}
+
+	latest := ""
+	for version := range published {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,472 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	types3 "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	types2 "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"godeploy/shared"
+	"strings"
+)
+
+//Statement IDs of the permissions added for triggers start with this prefix followed by the trigger type,
+//so triggers removed from the deployment file can be found in the resource policy of the function
+const triggerStatementPrefix = "godeploy-"
+
+//Id of the EventBridge targets and S3 notifications created for a function
+const triggerTargetID = "godeploy"
+
+//Clients and ARNs needed to manage the triggers of a single function
+type triggerContext struct {
+	lambda      *lambda.Client
+	eventBridge *eventbridge.Client
+	sns         *sns.Client
+	s3          *s3.Client
+	d           shared.Deployment
+	region      string
+	account     string
+	//Triggers invoke the live alias, so they follow rollbacks
+	aliasARN string
+}
+
+//Statement of the resource policy of a function
+type policyStatement struct {
+	Sid       string
+	Condition struct {
+		ArnLike map[string]string
+	}
+}
+
+//Creates the triggers of the deployment and removes the ones created by a previous deployment that are no longer defined
+func reconcileTriggers(cfg aws.Config, client *lambda.Client, d shared.Deployment, functionARN string) error {
+	//Function ARNs follow the format arn:aws:lambda:<REGION>:<ACCOUNT>:function:<NAME>
+	arnSplit := strings.Split(functionARN, ":")
+	t := triggerContext{
+		lambda:      client,
+		eventBridge: eventbridge.NewFromConfig(cfg),
+		sns:         sns.NewFromConfig(cfg),
+		s3:          s3.NewFromConfig(cfg),
+		d:           d,
+		region:      d.Region,
+		account:     arnSplit[4],
+		aliasARN:    fmt.Sprintf("%v:%v", strings.Join(arnSplit[:7], ":"), shared.AWSLiveAlias),
+	}
+
+	desired := make(map[string]shared.Trigger)
+	for _, trigger := range d.Triggers {
+		desired[t.statementID(trigger)] = trigger
+	}
+
+	current, err := t.getTriggerStatements()
+	if err != nil {
+		return err
+	}
+	for sid, sourceARN := range current {
+		if _, ok := desired[sid]; !ok {
+			if err = t.removeTrigger(sid, sourceARN); err != nil {
+				return err
+			}
+		}
+	}
+	if err = t.reconcileFunctionURL(d.Triggers); err != nil {
+		return err
+	}
+	if err = t.reconcileQueues(d.Triggers); err != nil {
+		return err
+	}
+
+	for sid, trigger := range desired {
+		_, exists := current[sid]
+		switch trigger.Type {
+		case shared.TriggerSchedule:
+			err = t.addSchedule(sid, trigger, exists)
+		case shared.TriggerTopic:
+			err = t.addTopic(sid, trigger, exists)
+		case shared.TriggerBucket:
+			err = t.addBucket(sid, trigger, exists)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Removes all triggers created for the function, before it is deleted
+func removeTriggers(cfg aws.Config, client *lambda.Client, d shared.Deployment) error {
+	output, err := client.GetFunction(context.Background(), &lambda.GetFunctionInput{FunctionName: &d.Name})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get function %v in region %v, Error: %v", d.Name, d.Region, err)
+	}
+
+	d.Triggers = nil
+	return reconcileTriggers(cfg, client, d, *output.Configuration.FunctionArn)
+}
+
+func (t triggerContext) statementID(trigger shared.Trigger) string {
+	if trigger.Type == shared.TriggerHTTP {
+		return triggerStatementPrefix + string(shared.TriggerHTTP)
+	}
+	return fmt.Sprintf("%v%v-%v", triggerStatementPrefix, trigger.Type, shared.ShortHash(trigger.Resource()))
+}
+
+//Returns the statement IDs of all permissions added for triggers, mapped to the ARN of their source
+func (t triggerContext) getTriggerStatements() (map[string]string, error) {
+	statements := make(map[string]string)
+	qualifier := shared.AWSLiveAlias
+	output, err := t.lambda.GetPolicy(context.Background(), &lambda.GetPolicyInput{FunctionName: &t.d.Name, Qualifier: &qualifier})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return statements, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get policy of function %v, Error: %v", t.d.Name, err)
+	}
+
+	var policy struct {
+		Statement []policyStatement
+	}
+	if err = json.Unmarshal([]byte(*output.Policy), &policy); err != nil {
+		return nil, fmt.Errorf("unable to parse policy of function %v, Error: %v", t.d.Name, err)
+	}
+
+	for _, statement := range policy.Statement {
+		if strings.HasPrefix(statement.Sid, triggerStatementPrefix) {
+			statements[statement.Sid] = statement.Condition.ArnLike["AWS:SourceArn"]
+		}
+	}
+	return statements, nil
+}
+
+func (t triggerContext) addPermission(sid string, principal string, sourceARN string) error {
+	action := "lambda:InvokeFunction"
+	qualifier := shared.AWSLiveAlias
+	input := &lambda.AddPermissionInput{
+		FunctionName: &t.d.Name,
+		Qualifier:    &qualifier,
+		StatementId:  &sid,
+		Action:       &action,
+		Principal:    &principal,
+	}
+	if sourceARN != "" {
+		input.SourceArn = &sourceARN
+	}
+	if principal == "s3.amazonaws.com" {
+		input.SourceAccount = &t.account
+	}
+	if sid == triggerStatementPrefix+string(shared.TriggerHTTP) {
+		action = "lambda:InvokeFunctionUrl"
+		input.FunctionUrlAuthType = types.FunctionUrlAuthTypeNone
+	}
+
+	if _, err := t.lambda.AddPermission(context.Background(), input); err != nil {
+		return fmt.Errorf("unable to allow %v to invoke function %v, Error: %v", principal, t.d.Name, err)
+	}
+	return nil
+}
+
+func (t triggerContext) removePermission(sid string) error {
+	qualifier := shared.AWSLiveAlias
+	_, err := t.lambda.RemovePermission(context.Background(), &lambda.RemovePermissionInput{FunctionName: &t.d.Name, Qualifier: &qualifier, StatementId: &sid})
+	var notFound *types.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("unable to remove permission %v of function %v, Error: %v", sid, t.d.Name, err)
+	}
+	return nil
+}
+
+//Removes the trigger a permission was added for, the trigger type is part of the statement ID
+func (t triggerContext) removeTrigger(sid string, sourceARN string) error {
+	triggerType := shared.TriggerType(strings.Split(strings.TrimPrefix(sid, triggerStatementPrefix), "-")[0])
+	var err error
+	switch triggerType {
+	case shared.TriggerHTTP:
+		//The function URL and its permission are handled by reconcileFunctionURL
+		return nil
+	case shared.TriggerSchedule:
+		//Rule ARNs follow the format arn:aws:events:<REGION>:<ACCOUNT>:rule/<NAME>
+		rule := sourceARN[strings.LastIndex(sourceARN, "/")+1:]
+		_, err = t.eventBridge.RemoveTargets(context.Background(), &eventbridge.RemoveTargetsInput{Rule: &rule, Ids: []string{triggerTargetID}})
+		if err != nil {
+			return fmt.Errorf("unable to remove target of rule %v, Error: %v", rule, err)
+		}
+		if _, err = t.eventBridge.DeleteRule(context.Background(), &eventbridge.DeleteRuleInput{Name: &rule}); err != nil {
+			return fmt.Errorf("unable to delete rule %v, Error: %v", rule, err)
+		}
+	case shared.TriggerTopic:
+		err = t.unsubscribe(sourceARN)
+	case shared.TriggerBucket:
+		//Bucket ARNs follow the format arn:aws:s3:::<BUCKET>
+		err = t.putBucketNotification(sourceARN[strings.LastIndex(sourceARN, ":")+1:], nil)
+	}
+	if err != nil {
+		return err
+	}
+	if err = t.removePermission(sid); err != nil {
+		return err
+	}
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Removed %v trigger %v of function %v in region %v", triggerType, sourceARN, t.d.Name, t.region))
+	return nil
+}
+
+//Creates, updates or deletes the function URL of the live alias
+func (t triggerContext) reconcileFunctionURL(triggers []shared.Trigger) error {
+	qualifier := shared.AWSLiveAlias
+	sid := triggerStatementPrefix + string(shared.TriggerHTTP)
+	var http *shared.Trigger
+	for i := range triggers {
+		if triggers[i].Type == shared.TriggerHTTP {
+			http = &triggers[i]
+		}
+	}
+
+	_, err := t.lambda.GetFunctionUrlConfig(context.Background(), &lambda.GetFunctionUrlConfigInput{FunctionName: &t.d.Name, Qualifier: &qualifier})
+	var notFound *types.ResourceNotFoundException
+	exists := !errors.As(err, &notFound)
+	if exists && err != nil {
+		return fmt.Errorf("unable to get URL of function %v, Error: %v", t.d.Name, err)
+	}
+
+	if http == nil {
+		if exists {
+			_, err = t.lambda.DeleteFunctionUrlConfig(context.Background(), &lambda.DeleteFunctionUrlConfigInput{FunctionName: &t.d.Name, Qualifier: &qualifier})
+			if err != nil {
+				return fmt.Errorf("unable to delete URL of function %v, Error: %v", t.d.Name, err)
+			}
+			if err = t.removePermission(sid); err != nil {
+				return err
+			}
+			shared.Log(shared.ProviderAWS, fmt.Sprintf("Removed http trigger of function %v in region %v", t.d.Name, t.region))
+		}
+		return nil
+	}
+
+	authType := types.FunctionUrlAuthTypeAwsIam
+	if http.Public {
+		authType = types.FunctionUrlAuthTypeNone
+	}
+	var functionURL *string
+	if exists {
+		output, err := t.lambda.UpdateFunctionUrlConfig(context.Background(), &lambda.UpdateFunctionUrlConfigInput{FunctionName: &t.d.Name, Qualifier: &qualifier, AuthType: authType})
+		if err != nil {
+			return fmt.Errorf("unable to update URL of function %v, Error: %v", t.d.Name, err)
+		}
+		functionURL = output.FunctionUrl
+	} else {
+		output, err := t.lambda.CreateFunctionUrlConfig(context.Background(), &lambda.CreateFunctionUrlConfigInput{FunctionName: &t.d.Name, Qualifier: &qualifier, AuthType: authType})
+		if err != nil {
+			return fmt.Errorf("unable to create URL of function %v, Error: %v", t.d.Name, err)
+		}
+		functionURL = output.FunctionUrl
+	}
+
+	//Public URLs additionally need a permission for everyone
+	if err = t.removePermission(sid); err != nil {
+		return err
+	}
+	if http.Public {
+		if err = t.addPermission(sid, "*", ""); err != nil {
+			return err
+		}
+	}
+	shared.Log(shared.ProviderAWS, fmt.Sprintf("Function %v in region %v is available at %v", t.d.Name, t.region, *functionURL))
+	return nil
+}
+
+//Creates event source mappings for all queues of the deployment and deletes the mappings of other queues
+func (t triggerContext) reconcileQueues(triggers []shared.Trigger) error {
+	var queueARNs []string
+	for _, trigger := range triggers {
+		if trigger.Type == shared.TriggerQueue {
+			queueARNs = append(queueARNs, t.resourceARN("sqs", trigger.Queue))
+		}
+	}
+
+	var mappedARNs []string
+	paginator := lambda.NewListEventSourceMappingsPaginator(t.lambda, &lambda.ListEventSourceMappingsInput{FunctionName: &t.aliasARN})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return fmt.Errorf("unable to list event source mappings of function %v, Error: %v", t.d.Name, err)
+		}
+
+		for _, mapping := range page.EventSourceMappings {
+			if mapping.EventSourceArn == nil || !strings.HasPrefix(*mapping.EventSourceArn, "arn:aws:sqs:") {
+				continue
+			}
+			if shared.Contains(queueARNs, *mapping.EventSourceArn) {
+				mappedARNs = append(mappedARNs, *mapping.EventSourceArn)
+				continue
+			}
+			_, err = t.lambda.DeleteEventSourceMapping(context.Background(), &lambda.DeleteEventSourceMappingInput{UUID: mapping.UUID})
+			if err != nil {
+				return fmt.Errorf("unable to delete event source mapping of queue %v, Error: %v", *mapping.EventSourceArn, err)
+			}
+			shared.Log(shared.ProviderAWS, fmt.Sprintf("Removed queue trigger %v of function %v in region %v", *mapping.EventSourceArn, t.d.Name, t.region))
+		}
+	}
+
+	for _, queueARN := range queueARNs {
+		if shared.Contains(mappedARNs, queueARN) {
+			continue
+		}
+		arn := queueARN
+		_, err := t.lambda.CreateEventSourceMapping(context.Background(), &lambda.CreateEventSourceMappingInput{FunctionName: &t.aliasARN, EventSourceArn: &arn})
+		if err != nil {
+			return fmt.Errorf("unable to create event source mapping of queue %v, Error: %v", queueARN, err)
+		}
+		shared.Log(shared.ProviderAWS, fmt.Sprintf("Added queue trigger %v to function %v in region %v", queueARN, t.d.Name, t.region))
+	}
+	return nil
+}
+
+//Schedules are EventBridge rules, which are named after the function and the schedule
+func (t triggerContext) addSchedule(sid string, trigger shared.Trigger, exists bool) error {
+	name := fmt.Sprintf("godeploy-%v", shared.ShortHash(t.d.Name, trigger.Schedule))
+	expression := shared.AWSCronExpression(trigger.Schedule)
+	description := fmt.Sprintf("Schedule of function %v", t.d.Name)
+	rule, err := t.eventBridge.PutRule(context.Background(), &eventbridge.PutRuleInput{
+		Name:               &name,
+		ScheduleExpression: &expression,
+		Description:        &description,
+		State:              types3.RuleStateEnabled,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create rule %v, Error: %v", name, err)
+	}
+
+	targetID := triggerTargetID
+	_, err = t.eventBridge.PutTargets(context.Background(), &eventbridge.PutTargetsInput{
+		Rule:    &name,
+		Targets: []types3.Target{{Id: &targetID, Arn: &t.aliasARN}},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to add function %v to rule %v, Error: %v", t.d.Name, name, err)
+	}
+
+	if !exists {
+		if err = t.addPermission(sid, "events.amazonaws.com", *rule.RuleArn); err != nil {
+			return err
+		}
+		shared.Log(shared.ProviderAWS, fmt.Sprintf("Added schedule trigger %v to function %v in region %v", expression, t.d.Name, t.region))
+	}
+	return nil
+}
+
+func (t triggerContext) addTopic(sid string, trigger shared.Trigger, exists bool) error {
+	topicARN := t.resourceARN("sns", trigger.Topic)
+	if !exists {
+		if err := t.addPermission(sid, "sns.amazonaws.com", topicARN); err != nil {
+			return err
+		}
+	}
+
+	protocol := "lambda"
+	_, err := t.sns.Subscribe(context.Background(), &sns.SubscribeInput{TopicArn: &topicARN, Protocol: &protocol, Endpoint: &t.aliasARN})
+	if err != nil {
+		return fmt.Errorf("unable to subscribe function %v to topic %v, Error: %v", t.d.Name, topicARN, err)
+	}
+	if !exists {
+		shared.Log(shared.ProviderAWS, fmt.Sprintf("Added topic trigger %v to function %v in region %v", topicARN, t.d.Name, t.region))
+	}
+	return nil
+}
+
+func (t triggerContext) unsubscribe(topicARN string) error {
+	paginator := sns.NewListSubscriptionsByTopicPaginator(t.sns, &sns.ListSubscriptionsByTopicInput{TopicArn: &topicARN})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return fmt.Errorf("unable to list subscriptions of topic %v, Error: %v", topicARN, err)
+		}
+
+		for _, subscription := range page.Subscriptions {
+			if subscription.Endpoint != nil && *subscription.Endpoint == t.aliasARN {
+				_, err = t.sns.Unsubscribe(context.Background(), &sns.UnsubscribeInput{SubscriptionArn: subscription.SubscriptionArn})
+				if err != nil {
+					return fmt.Errorf("unable to unsubscribe function %v from topic %v, Error: %v", t.d.Name, topicARN, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//S3 validates notifications when they are added, so the permission has to exist before
+func (t triggerContext) addBucket(sid string, trigger shared.Trigger, exists bool) error {
+	if !exists {
+		if err := t.addPermission(sid, "s3.amazonaws.com", fmt.Sprintf("arn:aws:s3:::%v", trigger.Bucket)); err != nil {
+			return err
+		}
+	}
+
+	var events []types2.Event
+	for _, event := range trigger.BucketEvents() {
+		if event == shared.BucketEventDeleted {
+			events = append(events, types2.Event("s3:ObjectRemoved:*"))
+		} else {
+			events = append(events, types2.Event("s3:ObjectCreated:*"))
+		}
+	}
+	if err := t.putBucketNotification(trigger.Bucket, events); err != nil {
+		return err
+	}
+	if !exists {
+		shared.Log(shared.ProviderAWS, fmt.Sprintf("Added bucket trigger %v to function %v in region %v", trigger.Bucket, t.d.Name, t.region))
+	}
+	return nil
+}
+
+//Replaces the notification of the function in the bucket, without events the notification is removed.
+//Notifications of other functions and services are kept
+func (t triggerContext) putBucketNotification(bucket string, events []types2.Event) error {
+	current, err := t.s3.GetBucketNotificationConfiguration(context.Background(), &s3.GetBucketNotificationConfigurationInput{Bucket: &bucket})
+	if err != nil {
+		return fmt.Errorf("unable to get notifications of bucket %v, Error: %v", bucket, err)
+	}
+
+	id := fmt.Sprintf("%v-%v", triggerTargetID, t.d.Name)
+	configuration := &types2.NotificationConfiguration{
+		EventBridgeConfiguration: current.EventBridgeConfiguration,
+		QueueConfigurations:      current.QueueConfigurations,
+		TopicConfigurations:      current.TopicConfigurations,
+	}
+	for _, c := range current.LambdaFunctionConfigurations {
+		if c.Id == nil || *c.Id != id {
+			configuration.LambdaFunctionConfigurations = append(configuration.LambdaFunctionConfigurations, c)
+		}
+	}
+	if len(events) > 0 {
+		configuration.LambdaFunctionConfigurations = append(configuration.LambdaFunctionConfigurations, types2.LambdaFunctionConfiguration{
+			Id:                aws.String(id),
+			LambdaFunctionArn: &t.aliasARN,
+			Events:            events,
+		})
+	}
+
+	_, err = t.s3.PutBucketNotificationConfiguration(context.Background(), &s3.PutBucketNotificationConfigurationInput{Bucket: &bucket, NotificationConfiguration: configuration})
+	if err != nil {
+		return fmt.Errorf("unable to update notifications of bucket %v, Error: %v", bucket, err)
+	}
+	return nil
+}
+
+//Queues and topics can be given as name, which is resolved within the account and region of the function, or as ARN
+func (t triggerContext) resourceARN(service string, name string) string {
+	if strings.HasPrefix(name, "arn:") {
+		return name
+	}
+	return fmt.Sprintf("arn:aws:%v:%v:%v:%v", service, t.region, t.account, name)
+}
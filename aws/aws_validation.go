@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+//Maximum number of tags and length of their keys and values
+const (
+	maxTags           = 50
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+var limits = shared.ProviderLimits{
+	MinMemory:  128,
+	MaxMemory:  10240,
+	MinTimeout: 1,
+	MaxTimeout: 900,
+	Runtimes: []string{
+		"nodejs12.x", "nodejs14.x", "nodejs16.x",
+		"python3.7", "python3.8", "python3.9",
+		"java8", "java8.al2", "java11",
+		"ruby2.7", "go1.x", "dotnetcore3.1", "dotnet6",
+		"provided", "provided.al2",
+	},
+	Regions: []string{
+		"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+		"af-south-1", "ap-east-1", "ap-south-1",
+		"ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+		"ap-southeast-1", "ap-southeast-2", "ap-southeast-3",
+		"ca-central-1", "eu-central-1", "eu-west-1", "eu-west-2", "eu-west-3",
+		"eu-south-1", "eu-north-1", "me-south-1", "sa-east-1",
+	},
+	ReservedEnvironment: []string{
+		"AWS_LAMBDA_", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_EXECUTION_ENV",
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"LAMBDA_TASK_ROOT", "LAMBDA_RUNTIME_DIR", "_HANDLER", "_X_AMZN_TRACE_ID",
+	},
+	NetworkKeys: []string{"subnets", "securityGroups"},
+	//Provisioned concurrency can not exceed the reserved concurrency of the function
+	MinInstancesLimit: "reservedConcurrency",
+}
+
+func (Client) Validate(section string, key string, node *yaml.Node, report shared.ValidationReporter) {
+	switch key {
+	case "tags":
+		validateTags(node, report)
+	case "triggers":
+		validateTriggers(node, report)
+	}
+}
+
+func validateTags(tags *yaml.Node, report shared.ValidationReporter) {
+	for i := 0; i+1 < len(tags.Content); i += 2 {
+		keyNode, valueNode := tags.Content[i], tags.Content[i+1]
+		key := keyNode.Value
+		if key == "" || valueNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			report(keyNode, "tag %v uses the prefix aws: reserved by %v", key, shared.ProviderAWS)
+		}
+		if len(key) > maxTagKeyLength || len(valueNode.Value) > maxTagValueLength {
+			report(keyNode, "tag %v is too long for %v, keys are limited to %v and values to %v characters", key, shared.ProviderAWS, maxTagKeyLength, maxTagValueLength)
+		}
+	}
+	//The managed-by tag is added to every function
+	if count := len(tags.Content) / 2; count > maxTags-1 {
+		report(tags, "%v supports at most %v tags", shared.ProviderAWS, maxTags-1)
+	}
+}
+
+//EventBridge requires either day-of-month or day-of-week of a schedule to be ?
+func validateTriggers(triggers *yaml.Node, report shared.ValidationReporter) {
+	for _, trigger := range triggers.Content {
+		schedule := shared.GetValue(trigger, "schedule")
+		if trigger.Kind != yaml.MappingNode || schedule == nil || schedule.Kind != yaml.ScalarNode || shared.CheckSchedule(schedule.Value) != nil {
+			continue
+		}
+		if fields := strings.Fields(schedule.Value); fields[2] != "*" && fields[4] != "*" {
+			report(schedule, "schedule %v can not restrict both day-of-month and day-of-week on %v", schedule.Value, shared.ProviderAWS)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"godeploy/shared"
+	"golang.org/x/oauth2/clientcredentials"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const webAPIVersion = "2023-12-01"
+const roleAssignmentAPIVersion = "2022-04-01"
+const storageAPIVersion = "2021-08-06"
+
+//Metadata names of blobs may only contain letters, digits and underscores
+var invalidMetadataCharacters = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+//Blob storage accepts tokens of this scope for every storage account
+const storageScope = "https://storage.azure.com/.default"
+
+//Long running operations of Azure Resource Manager are polled in this interval, tests shorten it
+var operationPollInterval = 5 * time.Second
+const operationTimeout = 10 * time.Minute
+
+//Client for the REST APIs of Azure Resource Manager and Blob Storage, authenticated as the service principal of the credentials
+type apiClient struct {
+	credentials credentials
+	management  *http.Client
+	storage     *http.Client
+}
+
+//Error response of an Azure API, code and message are empty if the response did not contain them
+type apiError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("status %v: %v", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("status %v, %v: %v", e.StatusCode, e.Code, e.Message)
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+func isConflict(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.StatusCode == http.StatusConflict
+}
+
+func newAPIClient(cfg shared.Config) (*apiClient, error) {
+	c, ok := cfg.Credentials.Providers[shared.ProviderAzure].(credentials)
+	if !ok {
+		return nil, fmt.Errorf("credentials of %v are not loaded", shared.ProviderAzure)
+	}
+
+	tokenURL := fmt.Sprintf("%v/%v/oauth2/v2.0/token", c.AuthorityEndpoint, c.TenantID)
+	newClient := func(scope string) *http.Client {
+		config := clientcredentials.Config{ClientID: c.ClientID, ClientSecret: c.ClientSecret, TokenURL: tokenURL, Scopes: []string{scope}}
+		return config.Client(context.Background())
+	}
+	return &apiClient{
+		credentials: c,
+		management:  newClient(c.ManagementEndpoint + "/.default"),
+		storage:     newClient(storageScope),
+	}, nil
+}
+
+//Returns the ID of the resource group all Function Apps are deployed to
+func (c *apiClient) resourceGroupID() string {
+	return fmt.Sprintf("/subscriptions/%v/resourceGroups/%v", c.credentials.SubscriptionID, c.credentials.ResourceGroup)
+}
+
+func (c *apiClient) storageAccountID() string {
+	return fmt.Sprintf("%v/providers/Microsoft.Storage/storageAccounts/%v", c.resourceGroupID(), c.credentials.StorageAccount)
+}
+
+//Sends a request for the resource with the given ID to Azure Resource Manager and decodes the response into out.
+//Asynchronous operations are awaited, afterwards the resource is read again if out is set
+func (c *apiClient) manage(method string, resourceID string, apiVersion string, in interface{}, out interface{}) error {
+	requestURL := fmt.Sprintf("%v%v?api-version=%v", c.credentials.ManagementEndpoint, resourceID, apiVersion)
+	response, err := c.send(c.management, method, requestURL, in, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	operation := response.Header.Get("Azure-AsyncOperation")
+	if operation == "" && response.StatusCode == http.StatusAccepted {
+		operation = response.Header.Get("Location")
+	}
+	if operation != "" {
+		if err = c.waitForOperation(operation); err != nil {
+			return err
+		}
+		if out == nil || method == http.MethodDelete {
+			return nil
+		}
+		return c.manage(http.MethodGet, resourceID, apiVersion, nil, out)
+	}
+	return decodeResponse(response, out)
+}
+
+//Polls the status of an asynchronous operation until it is finished
+func (c *apiClient) waitForOperation(operationURL string) error {
+	deadline := time.Now().Add(operationTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(operationPollInterval)
+		response, err := c.send(c.management, http.MethodGet, operationURL, nil, nil)
+		if err != nil {
+			return err
+		}
+		//Location headers return 202 until the operation is finished, Azure-AsyncOperation headers report the status in the body
+		if response.StatusCode == http.StatusAccepted {
+			response.Body.Close()
+			continue
+		}
+
+		var status struct {
+			Status string `json:"status"`
+			Error  struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		err = decodeResponse(response, &status)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+		switch status.Status {
+		case "", "Succeeded":
+			return nil
+		case "Failed", "Canceled":
+			return fmt.Errorf("operation %v, %v: %v", strings.ToLower(status.Status), status.Error.Code, status.Error.Message)
+		}
+	}
+	return fmt.Errorf("operation did not finish within %v", operationTimeout)
+}
+
+//Sends the request and returns an error for responses with an error status, JSON request bodies are encoded from in
+func (c *apiClient) send(client *http.Client, method string, requestURL string, in interface{}, headers map[string]string) (*http.Response, error) {
+	var body io.Reader
+	switch value := in.(type) {
+	case nil:
+	case []byte:
+		body = bytes.NewReader(value)
+	default:
+		content, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize request to %v, Error: %v", requestURL, err)
+		}
+		body = bytes.NewReader(content)
+		headers = withHeader(headers, "Content-Type", "application/json")
+	}
+
+	request, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request to %v, Error: %v", requestURL, err)
+	}
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 400 {
+		defer response.Body.Close()
+		return nil, readError(response)
+	}
+	return response, nil
+}
+
+func withHeader(headers map[string]string, key string, value string) map[string]string {
+	result := map[string]string{key: value}
+	for k, v := range headers {
+		result[k] = v
+	}
+	return result
+}
+
+func decodeResponse(response *http.Response, out interface{}) error {
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response of %v, Error: %v", response.Request.URL.Path, err)
+	}
+	if out == nil || len(content) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("unable to parse response of %v, Error: %v", response.Request.URL.Path, err)
+	}
+	return nil
+}
+
+//Reads the error of a response, Resource Manager reports errors as JSON and Blob Storage as XML
+func readError(response *http.Response) error {
+	content, _ := io.ReadAll(response.Body)
+	e := &apiError{StatusCode: response.StatusCode, Message: strings.TrimSpace(string(content))}
+
+	var jsonError struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	var xmlError struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if json.Unmarshal(content, &jsonError) == nil && jsonError.Error.Code != "" {
+		e.Code, e.Message = jsonError.Error.Code, jsonError.Error.Message
+	} else if xml.Unmarshal(content, &xmlError) == nil && xmlError.Code != "" {
+		e.Code, e.Message = xmlError.Code, xmlError.Message
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(response.StatusCode)
+	}
+	return e
+}
+
+//Returns the URL of a blob in the storage account of the credentials
+func (c *apiClient) blobURL(container string, blob string) string {
+	return fmt.Sprintf("%v/%v/%v", c.credentials.StorageEndpoint, container, blob)
+}
+
+//Whether the archive is a blob URL of the storage account, which includes blobs of local stand-ins
+func (c *apiClient) isBlobURL(archive string) bool {
+	return shared.IsAzureObjectURI(archive) || strings.HasPrefix(archive, c.credentials.StorageEndpoint+"/")
+}
+
+func (c *apiClient) storageRequest(method string, requestURL string, body []byte, headers map[string]string) (*http.Response, error) {
+	headers = withHeader(headers, "x-ms-version", storageAPIVersion)
+	headers["x-ms-date"] = time.Now().UTC().Format(http.TimeFormat)
+	return c.send(c.storage, method, requestURL, body, headers)
+}
+
+//Creates the container in the storage account, containers that already exist are left unchanged
+func (c *apiClient) createContainer(container string) error {
+	response, err := c.storageRequest(http.MethodPut, fmt.Sprintf("%v/%v?restype=container", c.credentials.StorageEndpoint, container), nil, nil)
+	if isConflict(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to create container %v in storage account %v, Error: %v", container, c.credentials.StorageAccount, err)
+	}
+	response.Body.Close()
+	return nil
+}
+
+func (c *apiClient) deleteContainer(container string) error {
+	response, err := c.storageRequest(http.MethodDelete, fmt.Sprintf("%v/%v?restype=container", c.credentials.StorageEndpoint, container), nil, nil)
+	if isNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to delete container %v from storage account %v, Error: %v", container, c.credentials.StorageAccount, err)
+	}
+	response.Body.Close()
+	return nil
+}
+
+//Uploads the content as block blob, metadata names have to be valid C# identifiers
+func (c *apiClient) putBlob(blobURL string, content []byte, metadata map[string]string) error {
+	headers := map[string]string{"x-ms-blob-type": "BlockBlob", "Content-Type": "application/octet-stream"}
+	for key, value := range metadata {
+		headers["x-ms-meta-"+metadataName(key)] = value
+	}
+	response, err := c.storageRequest(http.MethodPut, blobURL, content, headers)
+	if err != nil {
+		return fmt.Errorf("unable to upload blob %v, Error: %v", blobURL, err)
+	}
+	response.Body.Close()
+	return nil
+}
+
+func (c *apiClient) getBlob(blobURL string) ([]byte, error) {
+	response, err := c.storageRequest(http.MethodGet, blobURL, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download blob %v, Error: %v", blobURL, err)
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download blob %v, Error: %v", blobURL, err)
+	}
+	return content, nil
+}
+
+func (c *apiClient) deleteBlob(blobURL string) error {
+	response, err := c.storageRequest(http.MethodDelete, blobURL, nil, nil)
+	if isNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to delete blob %v, Error: %v", blobURL, err)
+	}
+	response.Body.Close()
+	return nil
+}
+
+//Returns the names of all blobs of the container starting with the prefix, a missing container has no blobs
+func (c *apiClient) listBlobs(container string, prefix string) ([]string, error) {
+	var names []string
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		response, err := c.storageRequest(http.MethodGet, fmt.Sprintf("%v/%v?%v", c.credentials.StorageEndpoint, container, query.Encode()), nil, nil)
+		if isNotFound(err) {
+			return names, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to list blobs of container %v, Error: %v", container, err)
+		}
+
+		var result struct {
+			Blobs []struct {
+				Name string `xml:"Name"`
+			} `xml:"Blobs>Blob"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse blobs of container %v, Error: %v", container, err)
+		}
+		for _, blob := range result.Blobs {
+			names = append(names, blob.Name)
+		}
+		if result.NextMarker == "" {
+			return names, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+//Converts a tag key to a metadata name, by replacing characters that are not allowed in C# identifiers with underscores
+func metadataName(key string) string {
+	name := invalidMetadataCharacters.ReplaceAllString(key, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,299 @@
+package azure
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"godeploy/shared"
+	"godeploy/shared/providertest"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+//Hosts of the fake Azure cloud, all of them are served by the same test server
+const (
+	testManagementHost = "management.test"
+	testAuthorityHost  = "login.test"
+	testStorageHost    = "godeploytest.blob.test"
+)
+
+//Function Apps are returned in pages of this size when they are listed
+const testPageSize = 2
+
+//In memory stand-in for Resource Manager, Blob Storage and the Kudu deployment endpoint of the Function Apps
+type fakeAzure struct {
+	apps  map[string]functionApp
+	blobs map[string][]byte
+	//Archives deployed to the Function Apps, by Function App name
+	published map[string][]byte
+}
+
+//Starts a fake Azure cloud and routes all HTTP requests of the test to it, the returned config holds credentials for it
+func newFakeAzure(t *testing.T) (*fakeAzure, shared.Config) {
+	fake := &fakeAzure{apps: map[string]functionApp{}, blobs: map[string][]byte{}, published: map[string][]byte{}}
+	//Function Apps are published to hosts derived from their names, so all hosts are served by the same server
+	providertest.RouteDefaultTransport(t, providertest.NewTLSServer(t, http.HandlerFunc(fake.serve)))
+
+	pollInterval := operationPollInterval
+	operationPollInterval = time.Millisecond
+	t.Cleanup(func() { operationPollInterval = pollInterval })
+
+	//Plans, archives and deletions are only done once per process, which would hide them from later tests
+	createdPlans = make(map[string]bool)
+	uploadedArchives = make(map[string]bool)
+	deletedArchives = make(map[string]bool)
+
+	cfg := shared.Config{
+		Region: "westeurope",
+		Credentials: shared.CredentialsHolder{Providers: map[shared.ProviderName]interface{}{
+			shared.ProviderAzure: credentials{
+				TenantID:           "tenant",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				SubscriptionID:     "subscription",
+				ResourceGroup:      "group",
+				StorageAccount:     "godeploytest",
+				ManagementEndpoint: "https://" + testManagementHost,
+				AuthorityEndpoint:  "https://" + testAuthorityHost,
+				StorageEndpoint:    "https://" + testStorageHost,
+			},
+		}},
+	}
+	return fake, cfg
+}
+
+//Returns a deployment of the test function in the region of the config, within the limits of the Flex Consumption plan
+func newTestDeployment(t *testing.T, cfg shared.Config, version string, environment map[string]string) shared.Deployment {
+	d := providertest.NewDeployment(t, shared.ProviderAzure, cfg.Region, version, environment)
+	d.MemorySize = 2048
+	d.Timeout = 90
+	d.Runtime = "python3.11"
+	return d
+}
+
+func (f *fakeAzure) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Host == testAuthorityHost:
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{"access_token": "token", "token_type": "Bearer", "expires_in": 3600})
+	case r.Header.Get("Authorization") != "Bearer token":
+		providertest.WriteJSON(w, http.StatusUnauthorized, azureError("InvalidAuthenticationToken", "missing token"))
+	case r.Host == testManagementHost:
+		f.serveManagement(w, r, body)
+	case r.Host == testStorageHost:
+		f.serveStorage(w, r, body)
+	case strings.HasSuffix(r.Host, ".scm.azurewebsites.net"):
+		f.serveDeployment(w, r, body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAzure) serveManagement(w http.ResponseWriter, r *http.Request, body []byte) {
+	path := r.URL.Path
+	sites := "/subscriptions/subscription/resourceGroups/group/providers/Microsoft.Web/sites"
+	switch {
+	case strings.Contains(path, "/serverfarms/") || strings.Contains(path, "/roleAssignments/"):
+		providertest.WriteJSON(w, http.StatusOK, map[string]string{})
+	case path == sites && r.Method == http.MethodGet:
+		f.listApps(w, r)
+	case strings.HasPrefix(path, sites+"/"):
+		parts := strings.SplitN(strings.TrimPrefix(path, sites+"/"), "/", 2)
+		app, ok := f.apps[parts[0]]
+		switch {
+		case r.Method == http.MethodPut && len(parts) == 1:
+			f.putApp(w, parts[0], body)
+		case !ok:
+			providertest.WriteJSON(w, http.StatusNotFound, azureError("ResourceNotFound", fmt.Sprintf("Function App %v was not found", parts[0])))
+		case r.Method == http.MethodGet && len(parts) == 1:
+			providertest.WriteJSON(w, http.StatusOK, app)
+		case r.Method == http.MethodDelete && len(parts) == 1:
+			delete(f.apps, parts[0])
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost && parts[1] == "config/appsettings/list":
+			settings := map[string]string{}
+			for _, setting := range app.Properties.SiteConfig.AppSettings {
+				settings[setting.Name] = setting.Value
+			}
+			providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{"properties": settings})
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+//Function Apps are listed in pages linked by nextLink, like Resource Manager does for large resource groups
+func (f *fakeAzure) listApps(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for name := range f.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	skip := 0
+	fmt.Sscan(r.URL.Query().Get("$skiptoken"), &skip)
+	page := struct {
+		Value    []functionApp `json:"value"`
+		NextLink string        `json:"nextLink,omitempty"`
+	}{Value: []functionApp{}}
+	for i := skip; i < len(names) && i < skip+testPageSize; i++ {
+		page.Value = append(page.Value, f.apps[names[i]])
+	}
+	if skip+testPageSize < len(names) {
+		page.NextLink = fmt.Sprintf("https://%v%v?api-version=%v&$skiptoken=%v", testManagementHost, r.URL.Path, webAPIVersion, skip+testPageSize)
+	}
+	providertest.WriteJSON(w, http.StatusOK, page)
+}
+
+//Stores the Function App with the properties Resource Manager adds, the managed identity keeps its principal across updates
+func (f *fakeAzure) putApp(w http.ResponseWriter, name string, body []byte) {
+	var app functionApp
+	if err := json.Unmarshal(body, &app); err != nil {
+		providertest.WriteJSON(w, http.StatusBadRequest, azureError("InvalidRequestContent", err.Error()))
+		return
+	}
+	app.ID = "/subscriptions/subscription/resourceGroups/group/providers/Microsoft.Web/sites/" + name
+	app.Name = name
+	if app.Identity == nil {
+		app.Identity = &managedIdentity{Type: "SystemAssigned"}
+	}
+	app.Identity.PrincipalID = "principal-" + name
+	app.Properties.State = "Running"
+	app.Properties.EnabledHostNames = []string{name + ".azurewebsites.net", name + ".scm.azurewebsites.net"}
+	f.apps[name] = app
+	providertest.WriteJSON(w, http.StatusOK, app)
+}
+
+//Publishing is accepted immediately and reported as finished when its status is polled
+func (f *fakeAzure) serveDeployment(w http.ResponseWriter, r *http.Request, body []byte) {
+	name := strings.TrimSuffix(r.Host, ".scm.azurewebsites.net")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/publish":
+		f.published[name] = body
+		w.Header().Set("Location", fmt.Sprintf("https://%v/api/deployments/latest", r.Host))
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/deployments/latest":
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{"status": deploymentSuccess, "complete": true})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+//Blobs are stored by container and name, containers only exist as long as they hold blobs or were created
+func (f *fakeAzure) serveStorage(w http.ResponseWriter, r *http.Request, body []byte) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+	switch {
+	case query.Get("comp") == "list":
+		f.listBlobs(w, path, query.Get("prefix"))
+	case query.Get("restype") == "container" && r.Method == http.MethodPut:
+		f.blobs[path+"/"] = nil
+		w.WriteHeader(http.StatusCreated)
+	case query.Get("restype") == "container" && r.Method == http.MethodDelete:
+		if _, ok := f.blobs[path+"/"]; !ok {
+			writeStorageError(w, http.StatusNotFound, "ContainerNotFound")
+			return
+		}
+		for key := range f.blobs {
+			if strings.HasPrefix(key, path+"/") {
+				delete(f.blobs, key)
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut:
+		f.blobs[path] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet:
+		content, ok := f.blobs[path]
+		if !ok {
+			writeStorageError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		w.Write(content)
+	case r.Method == http.MethodDelete:
+		if _, ok := f.blobs[path]; !ok {
+			writeStorageError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, path)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAzure) listBlobs(w http.ResponseWriter, container string, prefix string) {
+	if _, ok := f.blobs[container+"/"]; !ok {
+		writeStorageError(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+	type blob struct {
+		Name string `xml:"Name"`
+	}
+	result := struct {
+		XMLName xml.Name `xml:"EnumerationResults"`
+		Blobs   []blob   `xml:"Blobs>Blob"`
+	}{}
+	for key := range f.blobs {
+		name := strings.TrimPrefix(key, container+"/")
+		if strings.HasPrefix(key, container+"/") && name != "" && strings.HasPrefix(name, prefix) {
+			result.Blobs = append(result.Blobs, blob{Name: name})
+		}
+	}
+	sort.Slice(result.Blobs, func(i, j int) bool { return result.Blobs[i].Name < result.Blobs[j].Name })
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+//Returns the blobs of the archive container starting with the prefix
+func (f *fakeAzure) archiveBlobs(prefix string) []string {
+	var names []string
+	for key := range f.blobs {
+		if strings.HasPrefix(key, shared.ArchiveBucketName+"/"+prefix) {
+			names = append(names, strings.TrimPrefix(key, shared.ArchiveBucketName+"/"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func azureError(code string, message string) map[string]interface{} {
+	return map[string]interface{}{"error": map[string]string{"code": code, "message": message}}
+}
+
+func writeStorageError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%v</Code><Message>%v</Message></Error>", code, http.StatusText(status))
+}
+
+func TestReadErrorParsesJSONAndXML(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"error":{"code":"ResourceNotFound","message":"not found"}}`, "status 404, ResourceNotFound: not found"},
+		{`<Error><Code>BlobNotFound</Code><Message>missing</Message></Error>`, "status 404, BlobNotFound: missing"},
+		{``, "status 404: Not Found"},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		recorder.WriteHeader(http.StatusNotFound)
+		recorder.WriteString(test.body)
+
+		err := readError(recorder.Result())
+		if err.Error() != test.want {
+			t.Errorf("readError(%q) = %q, want %q", test.body, err, test.want)
+		}
+		if !isNotFound(err) {
+			t.Errorf("isNotFound(readError(%q)) = false, want true", test.body)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package azure
+
+import (
+	"bytes"
+	"fmt"
+	"godeploy/shared"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Implementation of shared.Client for Azure Functions, every function is deployed as its own Function App per region
+type Client struct{}
+
+//Azure only keeps logs in Application Insights, which is not connected by GoDeploy, so this is only reported once
+var logsNotice sync.Once
+
+//Function Apps are created or updated depending on whether they exist, as Resource Manager replaces them in both cases
+func (Client) CreateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d)
+}
+
+func (Client) UpdateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d)
+}
+
+//Lists the Function Apps of the resource group in the given region, or in all regions if no region is given
+func (Client) ListFunctions(cfg shared.Config) ([]shared.Function, error) {
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var f []shared.Function
+	requestURL := fmt.Sprintf("%v%v/providers/Microsoft.Web/sites?api-version=%v", client.credentials.ManagementEndpoint, client.resourceGroupID(), webAPIVersion)
+	for requestURL != "" {
+		var page struct {
+			Value    []functionApp `json:"value"`
+			NextLink string        `json:"nextLink"`
+		}
+		response, err := client.send(client.management, http.MethodGet, requestURL, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list Function Apps of resource group %v, Error: %v", client.credentials.ResourceGroup, err)
+		}
+		err = decodeResponse(response, &page)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, app := range page.Value {
+			if strings.Contains(app.Kind, "functionapp") && (cfg.Region == "" || normalizeRegion(app.Location) == cfg.Region) {
+				f = append(f, mapFunctionApp(app, nil))
+			}
+		}
+		requestURL = page.NextLink
+	}
+	return f, nil
+}
+
+func (Client) GetFunction(cfg shared.Config, name string) (*shared.Function, error) {
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	appName := functionAppName(name, cfg.Region)
+	app, err := client.getFunctionApp(appName)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Function App %v, Error: %v", appName, err)
+	}
+
+	settings, err := client.getAppSettings(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	f := mapFunctionApp(app, settings)
+	return &f, nil
+}
+
+//The handler is not compared, as Azure reads the entry points of the functions from the archive
+func (Client) DesiredFunction(d shared.Deployment) shared.Function {
+	return shared.Function{
+		Name:         d.Name,
+		Provider:     shared.ProviderAzure,
+		Region:       d.Region,
+		Runtime:      d.Runtime,
+		MemorySize:   d.MemorySize,
+		Timeout:      d.Timeout,
+		Environment:  d.Environment,
+		MaxInstances: getMaxInstances(d),
+		MinInstances: d.MinInstances,
+	}
+}
+
+//Calls the first http triggered function of the Function App with its default function key
+func (Client) InvokeFunction(cfg shared.Config, name string, payload []byte) shared.Invocation {
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: err.Error()}
+	}
+
+	appID := client.functionAppID(functionAppName(name, cfg.Region))
+	var functions struct {
+		Value []struct {
+			Properties struct {
+				InvokeURLTemplate string `json:"invoke_url_template"`
+			} `json:"properties"`
+		} `json:"value"`
+	}
+	err = client.manage(http.MethodGet, appID+"/functions", webAPIVersion, nil, &functions)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to list functions of %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+
+	invokeURL := ""
+	for _, function := range functions.Value {
+		if function.Properties.InvokeURLTemplate != "" {
+			invokeURL = function.Properties.InvokeURLTemplate
+			break
+		}
+	}
+	if invokeURL == "" {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("function %v in region %v has no http triggered function to invoke", name, cfg.Region)}
+	}
+
+	var keys struct {
+		MasterKey    string            `json:"masterKey"`
+		FunctionKeys map[string]string `json:"functionKeys"`
+	}
+	err = client.manage(http.MethodPost, appID+"/host/default/listkeys", webAPIVersion, nil, &keys)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to get keys of function %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+	key := shared.Override(keys.MasterKey, keys.FunctionKeys["default"])
+
+	request, err := http.NewRequest(http.MethodPost, invokeURL, bytes.NewReader(payload))
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to invoke function %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("x-functions-key", key)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to invoke function %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return shared.Invocation{Status: fmt.Sprint(response.StatusCode), Error: fmt.Sprintf("unable to read response of function %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+	invocation := shared.Invocation{
+		Status: fmt.Sprint(response.StatusCode),
+		Body:   string(body),
+	}
+	if response.StatusCode >= 400 {
+		invocation.Error = response.Status
+	}
+	return invocation
+}
+
+func (Client) GetLogs(cfg shared.Config, name string, since time.Time) ([]shared.LogEntry, error) {
+	logsNotice.Do(func() {
+		shared.Log(shared.ProviderAzure, "Logs of Azure Functions are stored in Application Insights and can not be shown")
+	})
+	return nil, nil
+}
+
+//Maps a Function App to a function, settings are only available when reading a single Function App and can be nil
+func mapFunctionApp(app functionApp, settings map[string]string) shared.Function {
+	f := shared.Function{
+		Name:     shared.Override(app.Name, app.Tags[shared.AzureFunctionTag]),
+		Provider: shared.ProviderAzure,
+		Region:   normalizeRegion(app.Location),
+		State:    app.Properties.State,
+		Failed:   app.Properties.AvailabilityState != "" && app.Properties.AvailabilityState != "Normal",
+	}
+	if config := app.Properties.FunctionAppConfig; config != nil {
+		f.Runtime = config.Runtime.Name + config.Runtime.Version
+		f.MemorySize = config.ScaleAndConcurrency.InstanceMemoryMB
+		f.MaxInstances = config.ScaleAndConcurrency.MaximumInstanceCount
+		for _, group := range config.ScaleAndConcurrency.AlwaysReady {
+			if group.Name == httpInstanceGroup {
+				f.MinInstances = group.InstanceCount
+			}
+		}
+	}
+	if settings != nil {
+		f.Timeout = parseTimeout(settings[timeoutSetting])
+		f.Environment = make(map[string]string)
+		for key, value := range settings {
+			if !shared.IsReservedVariable(shared.ProviderAzure, key) {
+				f.Environment[key] = value
+			}
+		}
+	}
+	return f
+}
+
+//Resource Manager reports locations by their display name, e.g. West Europe for westeurope
+func normalizeRegion(location string) string {
+	return strings.ToLower(strings.ReplaceAll(location, " ", ""))
+}
@@ -0,0 +1,87 @@
+package azure
+
+import (
+	"godeploy/shared"
+	"godeploy/shared/providertest"
+	"testing"
+)
+
+func TestLifecycle(t *testing.T) {
+	fake, cfg := newFakeAzure(t)
+	appName := functionAppName(providertest.FunctionName, cfg.Region)
+
+	providertest.Lifecycle{
+		Client: Client{},
+		Config: cfg,
+		Deployment: func(version string) shared.Deployment {
+			d := newTestDeployment(t, cfg, version, map[string]string{"VERSION": version})
+			d.MaxInstances = 40
+			d.MinInstances = 1
+			return d
+		},
+		AfterDeploy: func(t *testing.T, d shared.Deployment, result shared.DeploymentResult) {
+			app := fake.apps[appName]
+			if result.Function != app.ID {
+				t.Errorf("function = %v, want the ID of Function App %v", result.Function, app.ID)
+			}
+			if app.Tags[shared.AzureVersionTag] != d.Version || app.Tags[shared.AzureFunctionTag] != d.Name {
+				t.Errorf("tags = %v, want version %v of function %v", app.Tags, d.Version, d.Name)
+			}
+			if string(fake.published[appName]) != "archive "+d.Version {
+				t.Errorf("published archive = %q, want the archive of %v", fake.published[appName], d.Version)
+			}
+			archive := shared.ArchiveKey(d.Name, d.Version)
+			if _, ok := fake.blobs[shared.ArchiveBucketName+"/"+archive]; !ok {
+				t.Errorf("archive %v was not uploaded", archive)
+			}
+			if _, ok := fake.blobs[shared.ArchiveBucketName+"/"+historyKey(d.Name, d.Region, d.Version)]; !ok {
+				t.Errorf("deployment of %v was not recorded", d.Version)
+			}
+		},
+		AfterDelete: func(t *testing.T) {
+			if blobs := fake.archiveBlobs(providertest.FunctionName + "/"); len(blobs) != 0 {
+				t.Errorf("archive blobs after DeleteFunction = %v, want none", blobs)
+			}
+			if _, ok := fake.blobs[deploymentContainer(appName)+"/"]; ok {
+				t.Error("deployment container still exists after DeleteFunction")
+			}
+		},
+	}.Run(t)
+}
+
+func TestListFunctionsFollowsNextLink(t *testing.T) {
+	_, cfg := newFakeAzure(t)
+	client := Client{}
+
+	for _, region := range []string{"westeurope", "eastus", "northeurope"} {
+		regionCfg := shared.Config{Region: region, Credentials: cfg.Credentials}
+		if result := client.CreateFunction(regionCfg, newTestDeployment(t, regionCfg, "v1", nil)); result.Err != nil {
+			t.Fatalf("CreateFunction in %v failed: %v", region, result.Err)
+		}
+	}
+
+	//Three Function Apps are returned in two pages
+	all, err := client.ListFunctions(shared.Config{Credentials: cfg.Credentials})
+	if err != nil {
+		t.Fatalf("ListFunctions failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("ListFunctions returned %v functions, want 3", len(all))
+	}
+	for _, f := range all {
+		if f.Name != providertest.FunctionName {
+			t.Errorf("function name = %v, want the name of the function instead of the Function App", f.Name)
+		}
+	}
+
+	inRegion, err := client.ListFunctions(shared.Config{Region: "eastus", Credentials: cfg.Credentials})
+	if err != nil || len(inRegion) != 1 || inRegion[0].Region != "eastus" {
+		t.Errorf("ListFunctions in eastus = %+v, %v, want the function in eastus", inRegion, err)
+	}
+}
+
+func TestNormalizeRegion(t *testing.T) {
+	if got := normalizeRegion("West Europe"); got != "westeurope" {
+		t.Errorf("normalizeRegion(West Europe) = %v, want westeurope", got)
+	}
+}
@@ -0,0 +1,477 @@
+package azure
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Function App resource of Azure Resource Manager, only the properties used by GoDeploy are mapped
+type functionApp struct {
+	ID         string                `json:"id,omitempty"`
+	Name       string                `json:"name,omitempty"`
+	Location   string                `json:"location"`
+	Kind       string                `json:"kind"`
+	Tags       map[string]string     `json:"tags,omitempty"`
+	Identity   *managedIdentity      `json:"identity,omitempty"`
+	Properties functionAppProperties `json:"properties"`
+}
+
+type managedIdentity struct {
+	Type        string `json:"type"`
+	PrincipalID string `json:"principalId,omitempty"`
+}
+
+type functionAppProperties struct {
+	ServerFarmID      string             `json:"serverFarmId,omitempty"`
+	State             string             `json:"state,omitempty"`
+	AvailabilityState string             `json:"availabilityState,omitempty"`
+	DefaultHostName   string             `json:"defaultHostName,omitempty"`
+	EnabledHostNames  []string           `json:"enabledHostNames,omitempty"`
+	FunctionAppConfig *functionAppConfig `json:"functionAppConfig,omitempty"`
+	SiteConfig        *siteConfig        `json:"siteConfig,omitempty"`
+}
+
+//Configuration of Function Apps on the Flex Consumption plan
+type functionAppConfig struct {
+	Deployment          deploymentConfig `json:"deployment"`
+	Runtime             runtimeConfig    `json:"runtime"`
+	ScaleAndConcurrency scaleConfig      `json:"scaleAndConcurrency"`
+}
+
+//Container of the storage account the deployed package is stored in
+type deploymentConfig struct {
+	Storage struct {
+		Type           string `json:"type"`
+		Value          string `json:"value"`
+		Authentication struct {
+			Type string `json:"type"`
+		} `json:"authentication"`
+	} `json:"storage"`
+}
+
+type runtimeConfig struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type scaleConfig struct {
+	MaximumInstanceCount int32         `json:"maximumInstanceCount"`
+	InstanceMemoryMB     int32         `json:"instanceMemoryMB"`
+	AlwaysReady          []alwaysReady `json:"alwaysReady,omitempty"`
+}
+
+//Instances kept warm for a group of functions, e.g. http for all http triggered functions
+type alwaysReady struct {
+	Name          string `json:"name"`
+	InstanceCount int32  `json:"instanceCount"`
+}
+
+type siteConfig struct {
+	AppSettings []appSetting `json:"appSettings,omitempty"`
+}
+
+type appSetting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+//App settings managed by GoDeploy, which are not reported as environment variables of the function
+const timeoutSetting = "AzureFunctionsJobHost__functionTimeout"
+const storageAccountSetting = "AzureWebJobsStorage__accountName"
+
+//Instances of http triggered functions are kept warm in this group
+const httpInstanceGroup = "http"
+
+//Built-in role allowing the Function App to read its package and use the storage account of the Functions host
+const storageBlobDataOwnerRole = "b7e6dc6d-f1e8-4753-8033-0f276bb0955b"
+
+//Runtimes are given as stack name followed by version, e.g. python3.11 or dotnet-isolated8.0
+var runtimePattern = regexp.MustCompile(`^([a-z-]+?)(\d[\d.]*)$`)
+
+//Function App names may only contain lowercase letters, digits and single dashes
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+//Kudu reports the status of a deployment as number
+const (
+	deploymentFailed  = 3
+	deploymentSuccess = 4
+)
+
+//Plans are shared by all Function Apps of a region and only created once
+var planLock sync.Mutex
+var createdPlans = make(map[string]bool)
+
+//Archives are shared by all regions, so every archive is only uploaded once
+var uploadArchiveLock sync.Mutex
+var uploadedArchives = make(map[string]bool)
+
+//Uploads the archive to the godeploy-deployments container of the storage account, the deployment references the uploaded archive by its blob URL
+func (Client) UploadArchive(cfg shared.Config, d shared.Deployment) (shared.Deployment, error) {
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return d, err
+	}
+	if client.isBlobURL(d.Archive) {
+		return d, nil
+	} else if shared.IsAWSObjectURI(d.Archive) || shared.IsGoogleObjectURI(d.Archive) {
+		return d, fmt.Errorf("archive %v is stored at another provider, %v only supports local archives and blobs", d.Archive, shared.ProviderAzure)
+	}
+
+	key := shared.ArchiveKey(d.Name, d.Version)
+	blobURL := client.blobURL(shared.ArchiveBucketName, key)
+
+	uploadArchiveLock.Lock()
+	defer uploadArchiveLock.Unlock()
+	if !uploadedArchives[key] {
+		start := time.Now()
+		content, err := os.ReadFile(d.Archive)
+		if err != nil {
+			return d, fmt.Errorf("unable to read archive %v, Error: %v", d.Archive, err)
+		}
+		if err = client.createContainer(shared.ArchiveBucketName); err != nil {
+			return d, err
+		}
+		if err = client.putBlob(blobURL, content, d.Tags); err != nil {
+			return d, err
+		}
+		uploadedArchives[key] = true
+		shared.Log(shared.ProviderAzure, fmt.Sprintf("Location of archive: %v, upload took %s", blobURL, time.Since(start)))
+	}
+
+	d.Bucket = shared.ArchiveBucketName
+	d.Key = key
+	d.Archive = blobURL
+	return d, nil
+}
+
+//Creates or updates the Function App of the deployment, depending on whether it already exists, and deploys its archive
+func deploy(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	start := time.Now()
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	result := shared.NewDeploymentResult(d)
+
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	if !client.isBlobURL(d.Archive) {
+		if d, err = (Client{}).UploadArchive(cfg, d); err != nil {
+			return result.Fail(err, start)
+		}
+	}
+
+	name := functionAppName(d.Name, d.Region)
+	result.Action = shared.ActionUpdate
+	if _, err = client.getFunctionApp(name); isNotFound(err) {
+		result.Action = shared.ActionCreate
+	} else if err != nil {
+		return result.Fail(fmt.Errorf("unable to get Function App %v, Error: %v", name, err), start)
+	}
+	shared.Log(shared.ProviderAzure, fmt.Sprintf("Started deploying function %v as Function App %v in region %v with %v MB memory", d.Name, name, d.Region, d.MemorySize))
+
+	if err = client.createPlan(d.Region); err != nil {
+		return result.Fail(err, start)
+	}
+	if err = client.createContainer(deploymentContainer(name)); err != nil {
+		return result.Fail(err, start)
+	}
+	app, err := client.putFunctionApp(d)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	//Functions are identified by the resource ID of their Function App
+	result.Function = app.ID
+	if err = client.grantStorageAccess(app); err != nil {
+		return result.Fail(err, start)
+	}
+	if err = client.publishArchive(app, d.Archive); err != nil {
+		return result.Fail(err, start)
+	}
+	if err = client.recordDeployment(d); err != nil {
+		return result.Fail(err, start)
+	}
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderAzure, fmt.Sprintf("Finished deploying function %v as Function App %v in region %v, took %s", d.Name, name, d.Region, result.Duration))
+	return result
+}
+
+//Returns the name of the Function App of a function in a region, names have to be unique across Azure and are limited to 60 characters
+func functionAppName(name string, region string) string {
+	appName := strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(name+"-"+region), "-"), "-")
+	if len(appName) > 60 {
+		appName = strings.TrimRight(appName[:60], "-")
+	}
+	return appName
+}
+
+//Returns the container the package of a Function App is deployed to, container names are limited to 63 characters
+func deploymentContainer(appName string) string {
+	container := "godeploy-" + appName
+	if len(container) > 63 {
+		container = strings.TrimRight(container[:63], "-")
+	}
+	return container
+}
+
+func (c *apiClient) functionAppID(name string) string {
+	return fmt.Sprintf("%v/providers/Microsoft.Web/sites/%v", c.resourceGroupID(), name)
+}
+
+func (c *apiClient) planID(region string) string {
+	return fmt.Sprintf("%v/providers/Microsoft.Web/serverfarms/godeploy-%v", c.resourceGroupID(), region)
+}
+
+func (c *apiClient) getFunctionApp(name string) (functionApp, error) {
+	var app functionApp
+	err := c.manage(http.MethodGet, c.functionAppID(name), webAPIVersion, nil, &app)
+	return app, err
+}
+
+//Returns the app settings of the Function App, which include the environment variables of the function
+func (c *apiClient) getAppSettings(name string) (map[string]string, error) {
+	var settings struct {
+		Properties map[string]string `json:"properties"`
+	}
+	if err := c.manage(http.MethodPost, c.functionAppID(name)+"/config/appsettings/list", webAPIVersion, nil, &settings); err != nil {
+		return nil, fmt.Errorf("unable to get app settings of Function App %v, Error: %v", name, err)
+	}
+	return settings.Properties, nil
+}
+
+//Creates the Flex Consumption plan of the region, which is shared by all Function Apps of the region
+func (c *apiClient) createPlan(region string) error {
+	planLock.Lock()
+	defer planLock.Unlock()
+	if createdPlans[region] {
+		return nil
+	}
+
+	plan := map[string]interface{}{
+		"location": region,
+		"kind":     "functionapp",
+		"sku":      map[string]string{"name": "FC1", "tier": "FlexConsumption"},
+		//Flex Consumption plans run on Linux
+		"properties": map[string]bool{"reserved": true},
+		"tags":       map[string]string{shared.ManagedByTag: shared.ManagedByValue},
+	}
+	if err := c.manage(http.MethodPut, c.planID(region), webAPIVersion, plan, nil); err != nil {
+		return fmt.Errorf("unable to create Flex Consumption plan in region %v, Error: %v", region, err)
+	}
+	createdPlans[region] = true
+	return nil
+}
+
+//Creates or replaces the Function App with the configuration of the deployment and returns it with its managed identity
+func (c *apiClient) putFunctionApp(d shared.Deployment) (functionApp, error) {
+	name := functionAppName(d.Name, d.Region)
+	config := &functionAppConfig{
+		Runtime: getRuntime(d.Runtime),
+		ScaleAndConcurrency: scaleConfig{
+			MaximumInstanceCount: getMaxInstances(d),
+			InstanceMemoryMB:     d.MemorySize,
+		},
+	}
+	config.Deployment.Storage.Type = "blobContainer"
+	config.Deployment.Storage.Value = fmt.Sprintf("%v/%v", c.credentials.StorageEndpoint, deploymentContainer(name))
+	config.Deployment.Storage.Authentication.Type = "SystemAssignedIdentity"
+	if d.MinInstances > 0 {
+		config.ScaleAndConcurrency.AlwaysReady = []alwaysReady{{Name: httpInstanceGroup, InstanceCount: d.MinInstances}}
+	}
+
+	app := functionApp{
+		Location: d.Region,
+		Kind:     "functionapp,linux",
+		Tags:     getTags(d),
+		Identity: &managedIdentity{Type: "SystemAssigned"},
+		Properties: functionAppProperties{
+			ServerFarmID:      c.planID(d.Region),
+			FunctionAppConfig: config,
+			SiteConfig:        &siteConfig{AppSettings: c.appSettings(d)},
+		},
+	}
+
+	var deployed functionApp
+	if err := c.manage(http.MethodPut, c.functionAppID(name), webAPIVersion, app, &deployed); err != nil {
+		return deployed, fmt.Errorf("unable to create Function App %v, Error: %v", name, err)
+	}
+	return deployed, nil
+}
+
+//Returns the environment variables of the deployment together with the app settings of the timeout and the storage account of the Functions host
+func (c *apiClient) appSettings(d shared.Deployment) []appSetting {
+	settings := []appSetting{
+		{Name: storageAccountSetting, Value: c.credentials.StorageAccount},
+		{Name: timeoutSetting, Value: formatTimeout(d.Timeout)},
+	}
+	var names []string
+	for name := range d.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		settings = append(settings, appSetting{Name: name, Value: d.Environment[name]})
+	}
+	return settings
+}
+
+//Allows the managed identity of the Function App to read its package and to use the storage account as Functions host storage
+func (c *apiClient) grantStorageAccess(app functionApp) error {
+	if app.Identity == nil || app.Identity.PrincipalID == "" {
+		return fmt.Errorf("Function App %v has no managed identity", app.Name)
+	}
+
+	assignment := map[string]interface{}{
+		"properties": map[string]string{
+			"roleDefinitionId": fmt.Sprintf("/subscriptions/%v/providers/Microsoft.Authorization/roleDefinitions/%v", c.credentials.SubscriptionID, storageBlobDataOwnerRole),
+			"principalId":      app.Identity.PrincipalID,
+			"principalType":    "ServicePrincipal",
+		},
+	}
+	err := c.manage(http.MethodPut, c.roleAssignmentID(app.Identity.PrincipalID), roleAssignmentAPIVersion, assignment, nil)
+	if err != nil && !isConflict(err) {
+		return fmt.Errorf("unable to grant Function App %v access to storage account %v, Error: %v", app.Name, c.credentials.StorageAccount, err)
+	}
+	return nil
+}
+
+//Role assignments are named by a UUID, which is derived from the principal so the assignment can be found again when the Function App is deleted
+func (c *apiClient) roleAssignmentID(principalID string) string {
+	hash := sha1.Sum([]byte(c.storageAccountID() + principalID + storageBlobDataOwnerRole))
+	hash[6] = (hash[6] & 0x0f) | 0x50
+	hash[8] = (hash[8] & 0x3f) | 0x80
+	uuid := fmt.Sprintf("%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+	return fmt.Sprintf("%v/providers/Microsoft.Authorization/roleAssignments/%v", c.storageAccountID(), uuid)
+}
+
+//Deploys the archive to the Function App with a zip deployment, which stores the package in the deployment container of the Function App
+func (c *apiClient) publishArchive(app functionApp, archive string) error {
+	scmHost := ""
+	for _, host := range app.Properties.EnabledHostNames {
+		if strings.Contains(host, ".scm.") {
+			scmHost = host
+		}
+	}
+	if scmHost == "" {
+		return fmt.Errorf("Function App %v has no deployment endpoint", app.Name)
+	}
+
+	content, err := c.getBlob(archive)
+	if err != nil {
+		return err
+	}
+	response, err := c.send(c.management, http.MethodPost, fmt.Sprintf("https://%v/api/publish?RemoteBuild=false", scmHost), content, map[string]string{"Content-Type": "application/zip"})
+	if err != nil {
+		return fmt.Errorf("unable to deploy archive to Function App %v, Error: %v", app.Name, err)
+	}
+	response.Body.Close()
+
+	//The deployment is accepted before it is finished, its status can be polled at the returned location
+	statusURL := response.Header.Get("Location")
+	if statusURL == "" {
+		statusURL = fmt.Sprintf("https://%v/api/deployments/latest", scmHost)
+	}
+	deadline := time.Now().Add(operationTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(operationPollInterval)
+		var deployment struct {
+			Status     int    `json:"status"`
+			StatusText string `json:"status_text"`
+			Complete   bool   `json:"complete"`
+		}
+		response, err := c.send(c.management, http.MethodGet, statusURL, nil, nil)
+		if err != nil {
+			return fmt.Errorf("unable to get deployment status of Function App %v, Error: %v", app.Name, err)
+		}
+		err = decodeResponse(response, &deployment)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+		if deployment.Status == deploymentFailed {
+			return fmt.Errorf("deployment of archive to Function App %v failed: %v", app.Name, deployment.StatusText)
+		} else if deployment.Status == deploymentSuccess && deployment.Complete {
+			return nil
+		}
+	}
+	return fmt.Errorf("deployment of archive to Function App %v did not finish within %v", app.Name, operationTimeout)
+}
+
+//Stores the deployment next to its archive, so rollbacks can restore the package and configuration of every version
+func (c *apiClient) recordDeployment(d shared.Deployment) error {
+	record, err := json.Marshal(d.WithoutSecrets())
+	if err != nil {
+		return fmt.Errorf("unable to serialize deployment of function %v, Error: %v", d.Name, err)
+	}
+	if err = c.putBlob(c.blobURL(shared.ArchiveBucketName, historyKey(d.Name, d.Region, d.Version)), record, nil); err != nil {
+		return fmt.Errorf("unable to write deployment history of function %v, Error: %v", d.Name, err)
+	}
+	return nil
+}
+
+func historyPrefix(name string, region string) string {
+	return fmt.Sprintf("%v/history/%v/", name, region)
+}
+
+func historyKey(name string, region string, version string) string {
+	return fmt.Sprintf("%v%v.json", historyPrefix(name, region), version)
+}
+
+//Adds the version and the function name to the tags, as Function App names can differ from the function name
+func getTags(d shared.Deployment) map[string]string {
+	tags := make(map[string]string)
+	for key, value := range d.Tags {
+		tags[key] = value
+	}
+	tags[shared.AzureVersionTag] = d.Version
+	tags[shared.AzureFunctionTag] = d.Name
+	return tags
+}
+
+func getRuntime(runtime string) runtimeConfig {
+	match := runtimePattern.FindStringSubmatch(runtime)
+	if match == nil {
+		return runtimeConfig{Name: runtime}
+	}
+	return runtimeConfig{Name: match[1], Version: match[2]}
+}
+
+//Formats the timeout in seconds as the time span expected by the Functions host
+func formatTimeout(timeout int32) string {
+	return fmt.Sprintf("%02d:%02d:%02d", timeout/3600, timeout/60%60, timeout%60)
+}
+
+//Parses a time span of the Functions host, returns 0 if it is not set or invalid
+func parseTimeout(timeout string) int32 {
+	var seconds int32
+	for _, part := range strings.Split(timeout, ":") {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + int32(value)
+	}
+	return seconds
+}
+
+func getMaxInstances(d shared.Deployment) int32 {
+	if d.MaxInstances > 0 {
+		return d.MaxInstances
+	}
+	return shared.DefaultMaxFunctionInstances
+}
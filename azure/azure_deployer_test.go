@@ -0,0 +1,43 @@
+package azure
+
+import (
+	"godeploy/shared"
+	"testing"
+)
+
+func TestDeployReportsFailureWithoutCredentials(t *testing.T) {
+	d := shared.Deployment{Name: "hello", Provider: shared.ProviderAzure, Region: "westeurope"}
+
+	result := Client{}.CreateFunction(shared.Config{Region: "westeurope"}, d)
+	if result.Err == nil {
+		t.Fatal("CreateFunction without credentials succeeded")
+	}
+}
+
+func TestFunctionAppName(t *testing.T) {
+	tests := []struct {
+		name   string
+		region string
+		want   string
+	}{
+		{"hello", "westeurope", "hello-westeurope"},
+		{"Hello_World", "eastus", "hello-world-eastus"},
+		{"a-very-long-function-name-that-does-not-fit-into-azure", "westeurope", "a-very-long-function-name-that-does-not-fit-into-azure-weste"},
+	}
+	for _, test := range tests {
+		if got := functionAppName(test.name, test.region); got != test.want {
+			t.Errorf("functionAppName(%v, %v) = %v, want %v", test.name, test.region, got, test.want)
+		}
+	}
+}
+
+func TestTimeoutRoundTrip(t *testing.T) {
+	for _, timeout := range []int32{0, 59, 90, 3600, 3723} {
+		if got := parseTimeout(formatTimeout(timeout)); got != timeout {
+			t.Errorf("parseTimeout(formatTimeout(%v)) = %v", timeout, got)
+		}
+	}
+	if got := parseTimeout(""); got != 0 {
+		t.Errorf("parseTimeout(\"\") = %v, want 0", got)
+	}
+}
@@ -0,0 +1,113 @@
+package azure
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+	"sort"
+	"strings"
+)
+
+//Public endpoints used if the credentials file does not replace them
+const defaultManagementEndpoint = "https://management.azure.com"
+const defaultAuthorityEndpoint = "https://login.microsoftonline.com"
+
+//Service principal and the resources shared by all Function Apps, read from azure-credentials.yaml
+type credentials struct {
+	TenantID           string
+	ClientID           string
+	ClientSecret       string
+	SubscriptionID     string
+	ResourceGroup      string
+	StorageAccount     string
+	ManagementEndpoint string
+	AuthorityEndpoint  string
+	StorageEndpoint    string
+}
+
+func init() {
+	shared.RegisterProvider(Client{})
+}
+
+func (Client) Name() shared.ProviderName {
+	return shared.ProviderAzure
+}
+
+func (Client) Limits() shared.ProviderLimits {
+	return limits
+}
+
+func (Client) DefaultRegion() string {
+	return shared.DefaultAzureRegion
+}
+
+func (Client) DefaultRuntime() string {
+	return "python3.11"
+}
+
+func (Client) CredentialsFile() string {
+	return shared.AzureCredentialsFile
+}
+
+func (Client) CredentialsTemplate() string {
+	return shared.AzureTenantIDKey + `: "<TENANT_ID>"
+` + shared.AzureClientIDKey + `: "<CLIENT_ID>"
+` + shared.AzureClientSecretKey + `: "<CLIENT_SECRET>"
+` + shared.AzureSubscriptionIDKey + `: "<SUBSCRIPTION_ID>"
+` + shared.AzureResourceGroupKey + `: "<RESOURCE_GROUP>"
+` + shared.AzureStorageAccountKey + `: "<STORAGE_ACCOUNT>"
+`
+}
+
+//The keys of the credentials file are read from the configuration, endpoints that are not set use the public Azure cloud
+func (Client) LoadCredentials(file string, credentialsHolder *shared.CredentialsHolder) error {
+	c := credentials{
+		TenantID:           viper.GetString(shared.AzureTenantIDKey),
+		ClientID:           viper.GetString(shared.AzureClientIDKey),
+		ClientSecret:       viper.GetString(shared.AzureClientSecretKey),
+		SubscriptionID:     viper.GetString(shared.AzureSubscriptionIDKey),
+		ResourceGroup:      viper.GetString(shared.AzureResourceGroupKey),
+		StorageAccount:     viper.GetString(shared.AzureStorageAccountKey),
+		ManagementEndpoint: strings.TrimSuffix(viper.GetString(shared.AzureManagementEndpointKey), "/"),
+		AuthorityEndpoint:  strings.TrimSuffix(viper.GetString(shared.AzureAuthorityEndpointKey), "/"),
+		StorageEndpoint:    strings.TrimSuffix(viper.GetString(shared.AzureStorageEndpointKey), "/"),
+	}
+
+	var missing []string
+	for key, value := range map[string]string{
+		shared.AzureTenantIDKey:       c.TenantID,
+		shared.AzureClientIDKey:       c.ClientID,
+		shared.AzureClientSecretKey:   c.ClientSecret,
+		shared.AzureSubscriptionIDKey: c.SubscriptionID,
+		shared.AzureResourceGroupKey:  c.ResourceGroup,
+		shared.AzureStorageAccountKey: c.StorageAccount,
+	} {
+		if value == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing keys %v in credentials file {%v}", strings.Join(missing, ", "), file)
+	}
+
+	if c.ManagementEndpoint == "" {
+		c.ManagementEndpoint = defaultManagementEndpoint
+	}
+	if c.AuthorityEndpoint == "" {
+		c.AuthorityEndpoint = defaultAuthorityEndpoint
+	}
+	if c.StorageEndpoint == "" {
+		c.StorageEndpoint = fmt.Sprintf("https://%v.blob.core.windows.net", c.StorageAccount)
+	}
+
+	if credentialsHolder.Providers == nil {
+		credentialsHolder.Providers = make(map[shared.ProviderName]interface{})
+	}
+	credentialsHolder.Providers[shared.ProviderAzure] = c
+	return nil
+}
+
+func (Client) IsArchiveURI(archive string) bool {
+	return shared.IsAzureObjectURI(archive)
+}
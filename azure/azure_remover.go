@@ -0,0 +1,92 @@
+package azure
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//The archive container is shared by all regions, so the archives of every function are only deleted once
+var deleteArchiveLock sync.Mutex
+var deletedArchives = make(map[string]bool)
+
+func (Client) DeleteFunction(cfg shared.Config, d shared.Deployment, removeArchive bool) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionDelete
+
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	if err = deleteFunction(client, d); err != nil {
+		return result.Fail(err, start)
+	}
+	if removeArchive {
+		if err = deleteArchive(client, d); err != nil {
+			return result.Fail(err, start)
+		}
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+//Deletes the Function App together with its deployment container and its access to the storage account, the plan of the region is kept
+func deleteFunction(client *apiClient, d shared.Deployment) error {
+	shared.Log(shared.ProviderAzure, fmt.Sprintf("Started deleting function %v in region %v", d.Name, d.Region))
+
+	start := time.Now()
+	name := functionAppName(d.Name, d.Region)
+	app, err := client.getFunctionApp(name)
+	if isNotFound(err) {
+		shared.Log(shared.ProviderAzure, fmt.Sprintf("Function %v in region %v does not exist, nothing to delete", d.Name, d.Region))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get Function App %v, Error: %v", name, err)
+	}
+
+	if err = client.manage(http.MethodDelete, client.functionAppID(name), webAPIVersion, nil, nil); err != nil {
+		return fmt.Errorf("unable to delete Function App %v, Error: %v", name, err)
+	}
+
+	//Role assignments are not deleted together with the managed identity they belong to
+	if app.Identity != nil && app.Identity.PrincipalID != "" {
+		err = client.manage(http.MethodDelete, client.roleAssignmentID(app.Identity.PrincipalID), roleAssignmentAPIVersion, nil, nil)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("unable to delete storage access of Function App %v, Error: %v", name, err)
+		}
+	}
+	if err = client.deleteContainer(deploymentContainer(name)); err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+	shared.Log(shared.ProviderAzure, fmt.Sprintf("Finished deleting function %v in region %v, took %s", d.Name, d.Region, elapsed))
+	return nil
+}
+
+//Deletes all archive versions and the deployment history of the function
+func deleteArchive(client *apiClient, d shared.Deployment) error {
+	deleteArchiveLock.Lock()
+	defer deleteArchiveLock.Unlock()
+	if deletedArchives[d.Name] {
+		return nil
+	}
+
+	blobs, err := client.listBlobs(shared.ArchiveBucketName, shared.ArchiveKey(d.Name, ""))
+	if err != nil {
+		return err
+	}
+	for _, blob := range blobs {
+		if err = client.deleteBlob(client.blobURL(shared.ArchiveBucketName, blob)); err != nil {
+			return err
+		}
+	}
+	deletedArchives[d.Name] = true
+
+	shared.Log(shared.ProviderAzure, fmt.Sprintf("Deleted %v archives of function %v from container %v", len(blobs), d.Name, shared.ArchiveBucketName))
+	return nil
+}
@@ -0,0 +1,27 @@
+package azure
+
+import (
+	"godeploy/shared"
+	"testing"
+)
+
+func TestDeleteFunctionKeepsArchives(t *testing.T) {
+	fake, cfg := newFakeAzure(t)
+	client := Client{}
+
+	d := newTestDeployment(t, cfg, "v1", nil)
+	if result := client.CreateFunction(cfg, d); result.Err != nil {
+		t.Fatalf("CreateFunction failed: %v", result.Err)
+	}
+
+	if result := client.DeleteFunction(cfg, d, false); result.Err != nil {
+		t.Fatalf("DeleteFunction failed: %v", result.Err)
+	}
+	if _, ok := fake.apps[functionAppName(d.Name, d.Region)]; ok {
+		t.Error("Function App still exists after DeleteFunction")
+	}
+	want := []string{historyKey(d.Name, d.Region, "v1"), shared.ArchiveKey(d.Name, "v1")}
+	if blobs := fake.archiveBlobs(d.Name + "/"); len(blobs) != 2 || blobs[0] != want[0] || blobs[1] != want[1] {
+		t.Errorf("archive blobs after DeleteFunction = %v, want %v", blobs, want)
+	}
+}
@@ -0,0 +1,86 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"strings"
+	"time"
+)
+
+//Deploys the archive and configuration recorded for a previous version of the function again
+func (Client) RollbackFunction(cfg shared.Config, d shared.Deployment, requestedVersion string) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionRollback
+
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	versions, err := getRecordedVersions(client, d)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	name := functionAppName(d.Name, d.Region)
+	app, err := client.getFunctionApp(name)
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to get Function App %v, Error: %v", name, err), start)
+	}
+
+	version, err := shared.RollbackVersion(versions, app.Tags[shared.AzureVersionTag], requestedVersion)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	result.Version = version
+	record, err := readDeploymentRecord(client, d, version)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	shared.Log(shared.ProviderAzure, fmt.Sprintf("Started rolling back function %v in region %v to version %v", d.Name, d.Region, version))
+	deployed := deploy(cfg, record)
+	if deployed.Err != nil {
+		return result.Fail(deployed.Err, start)
+	}
+	result.Function = deployed.Function
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderAzure, fmt.Sprintf("Finished rolling back function %v in region %v to version %v, took %s", d.Name, d.Region, version, result.Duration))
+	return result
+}
+
+//Returns the versions recorded by Deploy for the function in the region of the deployment
+func getRecordedVersions(client *apiClient, d shared.Deployment) ([]string, error) {
+	prefix := historyPrefix(d.Name, d.Region)
+	blobs, err := client.listBlobs(shared.ArchiveBucketName, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list deployment history of function %v, Error: %v", d.Name, err)
+	}
+
+	var versions []string
+	for _, blob := range blobs {
+		versions = append(versions, strings.TrimSuffix(strings.TrimPrefix(blob, prefix), ".json"))
+	}
+	return versions, nil
+}
+
+func readDeploymentRecord(client *apiClient, d shared.Deployment, version string) (shared.Deployment, error) {
+	content, err := client.getBlob(client.blobURL(shared.ArchiveBucketName, historyKey(d.Name, d.Region, version)))
+	if err != nil {
+		return shared.Deployment{}, fmt.Errorf("unable to read deployment history of function %v, Error: %v", d.Name, err)
+	}
+
+	var record shared.Deployment
+	if err = json.Unmarshal(content, &record); err != nil {
+		return shared.Deployment{}, fmt.Errorf("unable to parse deployment history of function %v, Error: %v", d.Name, err)
+	}
+
+	//Secrets are not recorded, so they are resolved again from the local environment
+	record, missing := record.ResolveSecrets()
+	if len(missing) > 0 {
+		return shared.Deployment{}, fmt.Errorf("unable to resolve secrets of version %v of function %v, local environment variables %v are not set", version, d.Name, strings.Join(missing, ", "))
+	}
+	return record, nil
+}
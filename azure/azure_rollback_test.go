@@ -0,0 +1,92 @@
+package azure
+
+import (
+	"godeploy/shared"
+	"strings"
+	"testing"
+)
+
+func TestRollbackFunction(t *testing.T) {
+	fake, cfg := newFakeAzure(t)
+	client := Client{}
+
+	for _, version := range []string{"v1", "v2"} {
+		if result := client.CreateFunction(cfg, newTestDeployment(t, cfg, version, map[string]string{"VERSION": version})); result.Err != nil {
+			t.Fatalf("CreateFunction of %v failed: %v", version, result.Err)
+		}
+	}
+
+	result := client.RollbackFunction(cfg, newTestDeployment(t, cfg, "", nil), "")
+	if result.Err != nil {
+		t.Fatalf("RollbackFunction failed: %v", result.Err)
+	}
+	if result.Action != shared.ActionRollback || result.Version != "v1" {
+		t.Errorf("result = %v to version %v, want %v to version v1", result.Action, result.Version, shared.ActionRollback)
+	}
+
+	app := fake.apps["hello-westeurope"]
+	if app.Tags[shared.AzureVersionTag] != "v1" {
+		t.Errorf("version tag = %v, want v1", app.Tags[shared.AzureVersionTag])
+	}
+	f, err := client.GetFunction(cfg, "hello")
+	if err != nil {
+		t.Fatalf("GetFunction failed: %v", err)
+	}
+	if f.Environment["VERSION"] != "v1" {
+		t.Errorf("environment = %v, want the environment of v1", f.Environment)
+	}
+	if string(fake.published["hello-westeurope"]) != "archive v1" {
+		t.Errorf("published archive = %q, want the archive of v1", fake.published["hello-westeurope"])
+	}
+}
+
+func TestRollbackFunctionResolvesSecrets(t *testing.T) {
+	fake, cfg := newFakeAzure(t)
+	client := Client{}
+	t.Setenv("GODEPLOY_TEST_TOKEN", "first")
+
+	for _, version := range []string{"v1", "v2"} {
+		d := newTestDeployment(t, cfg, version, map[string]string{"TOKEN": version + "-secret"})
+		d.Secrets = map[string]string{"TOKEN": "GODEPLOY_TEST_TOKEN"}
+		if result := client.CreateFunction(cfg, d); result.Err != nil {
+			t.Fatalf("CreateFunction of %v failed: %v", version, result.Err)
+		}
+	}
+	//Secret values are never written to the deployment history
+	for _, blob := range fake.archiveBlobs("hello/history/") {
+		if content := string(fake.blobs[shared.ArchiveBucketName+"/"+blob]); strings.Contains(content, "-secret") {
+			t.Errorf("deployment history %v contains a secret: %v", blob, content)
+		}
+	}
+
+	if result := client.RollbackFunction(cfg, newTestDeployment(t, cfg, "", nil), "v1"); result.Err != nil {
+		t.Fatalf("RollbackFunction failed: %v", result.Err)
+	}
+	f, err := client.GetFunction(cfg, "hello")
+	if err != nil {
+		t.Fatalf("GetFunction failed: %v", err)
+	}
+	if f.Environment["TOKEN"] != "first" {
+		t.Errorf("TOKEN = %v, want the value of the local environment variable", f.Environment["TOKEN"])
+	}
+}
+
+func TestRollbackFunctionFailures(t *testing.T) {
+	_, cfg := newFakeAzure(t)
+	client := Client{}
+
+	if result := client.CreateFunction(cfg, newTestDeployment(t, cfg, "v1", nil)); result.Err != nil {
+		t.Fatalf("CreateFunction failed: %v", result.Err)
+	}
+
+	//There is no version before the only one, and unknown versions can not be restored
+	for _, version := range []string{"", "v0"} {
+		result := client.RollbackFunction(cfg, newTestDeployment(t, cfg, "", nil), version)
+		if result.Err == nil {
+			t.Errorf("RollbackFunction to %q succeeded, want an error", version)
+		}
+		if result.Action != shared.ActionRollback {
+			t.Errorf("action = %v, want %v", result.Action, shared.ActionRollback)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package azure
+
+import (
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+//Maximum number of tags and length of their keys and values
+const (
+	maxTags           = 50
+	maxTagKeyLength   = 512
+	maxTagValueLength = 256
+)
+
+//Characters Azure does not allow in tag names
+const invalidTagCharacters = `<>%&\?/`
+
+//Limits of Function Apps on the Flex Consumption plan, which only offers fixed instance memory sizes
+var limits = shared.ProviderLimits{
+	MemorySizes: []int32{512, 2048, 4096},
+	MinTimeout:  1,
+	MaxTimeout:  3600,
+	Runtimes: []string{
+		"node18", "node20",
+		"python3.10", "python3.11",
+		"java11", "java17",
+		"dotnet-isolated8.0", "powershell7.4",
+	},
+	Regions: []string{
+		"eastus", "eastus2", "westus2", "westus3", "centralus", "northcentralus", "southcentralus",
+		"canadacentral", "brazilsouth", "northeurope", "westeurope", "uksouth", "swedencentral",
+		"germanywestcentral", "francecentral", "norwayeast", "switzerlandnorth",
+		"eastasia", "southeastasia", "japaneast", "koreacentral", "centralindia",
+		"australiaeast", "southafricanorth", "uaenorth",
+	},
+	ReservedEnvironment: []string{
+		"FUNCTIONS_", "WEBSITE_", "SCM_", "AzureWebJobsStorage", "AzureWebJobsStorage__", "AzureFunctionsJobHost__",
+		"APPLICATIONINSIGHTS_CONNECTION_STRING", "DEPLOYMENT_STORAGE_CONNECTION_STRING",
+	},
+	MinInstancesLimit: "maxInstances",
+}
+
+func (Client) Validate(section string, key string, node *yaml.Node, report shared.ValidationReporter) {
+	switch {
+	case key == "tags":
+		validateTags(node, report)
+	//Azure reads the triggers from the function bindings in the archive, so function level triggers are ignored
+	case key == "triggers" && section == "provider":
+		report(node, "triggers are not supported by %v, they are defined by the function bindings in the archive", shared.ProviderAzure)
+	}
+}
+
+func validateTags(tags *yaml.Node, report shared.ValidationReporter) {
+	for i := 0; i+1 < len(tags.Content); i += 2 {
+		keyNode, valueNode := tags.Content[i], tags.Content[i+1]
+		key := keyNode.Value
+		if key == "" || valueNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		if key == shared.AzureVersionTag || key == shared.AzureFunctionTag {
+			report(keyNode, "tag %v is set by GoDeploy on %v", key, shared.ProviderAzure)
+		}
+		if strings.ContainsAny(key, invalidTagCharacters) {
+			report(keyNode, "tag %v contains one of the characters %v not allowed by %v", key, invalidTagCharacters, shared.ProviderAzure)
+		}
+		if len(key) > maxTagKeyLength || len(valueNode.Value) > maxTagValueLength {
+			report(keyNode, "tag %v is too long for %v, keys are limited to %v and values to %v characters", key, shared.ProviderAzure, maxTagKeyLength, maxTagValueLength)
+		}
+	}
+	//The managed-by, version and function tags are added to every Function App
+	if count := len(tags.Content) / 2; count > maxTags-3 {
+		report(tags, "%v supports at most %v tags", shared.ProviderAzure, maxTags-3)
+	}
+}
@@ -0,0 +1,52 @@
+package azure
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+)
+
+//Returns the problems Validate reports for the given setting
+func validate(t *testing.T, section string, key string, content string) []string {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		t.Fatal(err)
+	}
+	var problems []string
+	Client{}.Validate(section, key, document.Content[0], func(node *yaml.Node, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%v: %v", node.Line, fmt.Sprintf(format, args...)))
+	})
+	return problems
+}
+
+func TestValidateTags(t *testing.T) {
+	problems := validate(t, "function", "tags", "team: x\n"+shared.AzureVersionTag+": v1\na/b: y\n")
+	want := []string{
+		"2: tag godeploy-version is set by GoDeploy on Azure",
+		`3: tag a/b contains one of the characters <>%&\?/ not allowed by Azure`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+
+	var tags strings.Builder
+	for i := 0; i < maxTags-2; i++ {
+		fmt.Fprintf(&tags, "tag%v: x\n", i)
+	}
+	if problems := validate(t, "function", "tags", tags.String()); len(problems) != 1 || !strings.Contains(problems[0], "at most 47 tags") {
+		t.Errorf("problems of %v tags = %q, want one about the maximum", maxTags-2, problems)
+	}
+}
+
+//Triggers of the function apply to the other providers, only triggers of the Azure provider section are rejected
+func TestValidateTriggers(t *testing.T) {
+	triggers := "- type: http\n"
+	if problems := validate(t, "function", "triggers", triggers); len(problems) > 0 {
+		t.Errorf("problems of function triggers = %q, want none", problems)
+	}
+	if problems := validate(t, "provider", "triggers", triggers); len(problems) != 1 {
+		t.Errorf("problems of provider triggers = %q, want one", problems)
+	}
+}
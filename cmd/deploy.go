@@ -1,24 +1,41 @@
 package cmd
 
 import (
-	"context"
+	"bytes"
 	"fmt"
-	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	my_aws "godeploy/aws"
-	"godeploy/google"
 	"godeploy/shared"
-	google2 "golang.org/x/oauth2/google"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"text/tabwriter"
+	"time"
 )
 
 var deploymentFile string
+//Variables given as <NAME>=<VALUE>, which override the variables section of the deployment file
+var deploymentVariables []string
+var deploymentStage string
+var dryRun bool
 var deploymentDtos []shared.DeploymentDto
 var credentials shared.CredentialsHolder
 
+//Credentials files replacing the ones of the providers, set by a stage
+var credentialsFiles = make(map[shared.ProviderName]string)
+
+//Providers whose credentials have already been loaded
+var loadedCredentials = make(map[shared.ProviderName]bool)
+
+//Allows regions to be given as plain region names
+var deploymentDecodeHook = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+	shared.RegionDecodeHook,
+))
+
 // deployCmd represents the deploy command
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
@@ -28,6 +45,10 @@ Ex.:
 	godeploy deploy -f deployment.yaml
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		if dryRun {
+			Plan()
+			return
+		}
 		Deploy()
 	},
 }
@@ -44,46 +65,126 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	//deployCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	deployCmd.Flags().StringVarP(&deploymentFile, "file", "f", "deployment.yaml", "If the non default deployment file should be used.")
+	deployCmd.Flags().BoolVar(&dryRun, "dry-run", false, "If the changes should only be shown instead of deployed.")
 }
 
 func checkConfig() {
-	viper.SetConfigName(deploymentFile)
-	err := viper.ReadInConfig()
-	shared.CheckErr(err, fmt.Sprintf("unable to find deployment file {%v}, Error: %v", deploymentFile, err))
+	readDeploymentFile()
+	loadDeploymentCredentials()
+}
+
+func readDeploymentFile() {
+	content, errors := shared.InterpolateDeploymentFile(getDeploymentFilePath(), getDeploymentVariables())
+	exitOnValidationErrors(errors)
+	err := viper.ReadConfig(bytes.NewReader(content))
+	shared.CheckErr(err, fmt.Sprintf("unable to read deployment file {%v}, Error: %v", deploymentFile, err))
 
-	err = viper.UnmarshalKey("functions", &deploymentDtos)
+	err = viper.UnmarshalKey("functions", &deploymentDtos, deploymentDecodeHook)
 	shared.CheckErr(err, fmt.Sprintf("unable to parse deployment file {%v}, Error: %v", deploymentFile, err))
 
+	if deploymentStage != "" {
+		applyStage(deploymentStage)
+	}
+
+	for i, deployment := range deploymentDtos {
+		if deployment.Source != nil { //Archives built from a source directory are only created by packageSources
+			deploymentDtos[i].Archive = shared.SourceArchivePath(packageDirectory, deployment.Name)
+		}
+	}
+}
+
+//Applies the settings of the given stage to all functions, before they are expanded into deployments
+func applyStage(name string) {
+	var stages []shared.Stage
+	err := viper.UnmarshalKey("stages", &stages, deploymentDecodeHook)
+	shared.CheckErr(err, fmt.Sprintf("unable to parse stages of deployment file {%v}, Error: %v", deploymentFile, err))
+
+	stageNames := shared.Map(stages, func(s shared.Stage) string { return s.Name })
+	index := -1
+	for i, stageName := range stageNames {
+		if stageName == name {
+			index = i
+		}
+	}
+	if index < 0 {
+		shared.CheckErr(name, fmt.Sprintf("unknown stage %v, stages of deployment file {%v} are %v", name, deploymentFile, stageNames))
+	}
+
+	stage := stages[index]
+	for i, dto := range deploymentDtos {
+		deploymentDtos[i] = stage.Apply(dto)
+	}
+	for provider, file := range stage.Credentials {
+		credentialsFiles[provider] = file
+	}
+	shared.Log("GoDeploy", fmt.Sprintf("Using stage %v", name))
+}
+
+//Returns the path of the deployment file, which can be given with or without extension or as - for stdin
+func getDeploymentFilePath() string {
+	return shared.ResolveDeploymentFile(deploymentFile)
+}
+
+func getDeploymentVariables() map[string]string {
+	variables := make(map[string]string)
+	for _, variable := range deploymentVariables {
+		name, value, found := strings.Cut(variable, "=")
+		if !found || name == "" {
+			shared.CheckErr(variable, fmt.Sprintf("unable to parse variable %v, variables must be given as <NAME>=<VALUE>", variable))
+		}
+		variables[name] = value
+	}
+	//The selected stage can be referenced with ${var:stage}
+	if _, ok := variables["stage"]; !ok && deploymentStage != "" {
+		variables["stage"] = deploymentStage
+	}
+	return variables
+}
+
+//Loads the credentials of every provider used by the deployment file, or storing one of its archives
+func loadDeploymentCredentials() {
 	for _, deployment := range deploymentDtos {
 		providerNames := shared.Map(deployment.Providers, func(provider shared.Provider) shared.ProviderName { return provider.Name })
 
-		if shared.Contains(providerNames, shared.ProviderAWS) || shared.IsAWSObjectURI(deployment.Archive) { //If necessary should load the AWS credentials
-			if credentials.AwsCredentials == nil {
-				loadCredentials(shared.AWSCredentialsFile)
-				credentials.AwsCredentials = &aws.Credentials{
-					AccessKeyID:     viper.GetString(shared.AWSAccessKey),
-					SecretAccessKey: viper.GetString(shared.AWSSecretAccessKey),
-					SessionToken:    viper.GetString(shared.AWSSessionTokenKey),
-				}
-			}
-		}
-		if shared.Contains(providerNames, shared.ProviderGoogle) || shared.IsGoogleObjectURI(deployment.Archive) { //If necessary should load the GCP credentials
-			if credentials.GoogleCredentials == nil {
-				loadCredentials(shared.GoogleCredentialsFile)
-				googleCredentials, err := google2.CredentialsFromJSON(
-					context.Background(),
-					shared.ReadFile(fmt.Sprintf("%v.%v", shared.GoogleCredentialsFile, shared.DefaultFileExtension)),
-					shared.OAuthStorageScope,
-					shared.OAuthFunctionScope,
-				)
-				shared.CheckErr(err, err)
-				credentials.GoogleCredentials = googleCredentials
+		for _, name := range shared.ProviderNames() {
+			provider, _ := shared.GetProvider(name)
+			if shared.Contains(providerNames, name) || provider.IsArchiveURI(deployment.Archive) {
+				loadProviderCredentials(name)
 			}
 		}
 	}
 }
 
+//Loads the credentials of the given provider, if they have not been loaded already
+func loadProviderCredentials(name shared.ProviderName) {
+	if loadedCredentials[name] {
+		return
+	}
+	provider := getProvider(name)
+	file := getCredentialsFile(provider)
+	loadCredentials(file)
+	err := provider.LoadCredentials(file, &credentials)
+	shared.CheckErr(err, err)
+	loadedCredentials[name] = true
+}
+
+//Returns the credentials file of the provider, unless it is replaced by the selected stage
+func getCredentialsFile(provider shared.ProviderPlugin) string {
+	if file, ok := credentialsFiles[provider.Name()]; ok {
+		return file
+	}
+	return provider.CredentialsFile()
+}
+
+//Returns the registered provider with the given name and exits if there is none
+func getProvider(name shared.ProviderName) shared.ProviderPlugin {
+	provider, ok := shared.GetProvider(name)
+	if !ok {
+		shared.CheckErr(name, fmt.Sprintf("unsupported provider %v, valid values are %v", name, shared.ValidProviders()))
+	}
+	return provider
+}
+
 func loadCredentials(credentialFile string) {
 	viper.SetConfigName(credentialFile)
 	err := viper.MergeInConfig()
@@ -92,27 +193,118 @@ func loadCredentials(credentialFile string) {
 
 func Deploy() {
 	var waitGroup sync.WaitGroup
-	var deployments []shared.Deployment
 
+	Validate()
 	checkConfig() //TODO Rename
+	packageSources()
+	deployments := resolveSecrets(getDeployments())
+
+	//TODO Upload Archive before goroutines
 
+	//All targets of a deployment share the same version, so they can be rolled back together
+	version := shared.NewVersion()
+	shared.Log("GoDeploy", fmt.Sprintf("Deploying version %v", version))
+
+	for i := range deployments {
+		deployments[i].Version = version
+		err := shared.CheckDeployment(deployments[i])
+		shared.CheckErr(err, fmt.Sprintf("deployment check failed, Error: %v\n", err))
+	}
+
+	//Targets are deployed independently, a failed target does not stop the others
+	results := make([]shared.DeploymentResult, len(deployments))
+	waitGroup.Add(len(deployments))
+	for i, deployment := range deployments {
+		go func(i int, deployment shared.Deployment) {
+			defer waitGroup.Done()
+			results[i] = deployTarget(getProvider(deployment.Provider), deployment)
+		}(i, deployment)
+	}
+	waitGroup.Wait()
+
+	if printDeploymentResults(results) > 0 {
+		os.Exit(1)
+	}
+}
+
+//Uploads the archive of the target and creates the function, which updates functions that already exist
+func deployTarget(provider shared.ProviderPlugin, d shared.Deployment) shared.DeploymentResult {
+	start := time.Now()
+	cfg := shared.Config{Region: d.Region, Credentials: credentials}
+
+	uploaded, err := provider.UploadArchive(cfg, d)
+	if err != nil {
+		return shared.NewDeploymentResult(d).Fail(err, start)
+	}
+	result := provider.CreateFunction(cfg, uploaded)
+	result.Duration = time.Since(start)
+	return result
+}
+
+//Prints a summary of all targets followed by the errors of the failed ones and returns the number of failed targets
+func printDeploymentResults(results []shared.DeploymentResult) int {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPROVIDER\tREGION\tACTION\tRESULT\tDURATION\tFUNCTION")
+	var failed []shared.DeploymentResult
+	for _, r := range results {
+		result := "OK"
+		if r.Err != nil {
+			result = "FAILED"
+			failed = append(failed, r)
+		}
+		action := string(r.Action)
+		if action == "" {
+			action = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%s\t%v\n", r.Name, r.Provider, r.Region, action, result, r.Duration.Round(time.Millisecond), r.Function)
+	}
+	w.Flush()
+
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "\n%v of %v targets failed:\n", len(failed), len(results))
+		for _, r := range failed {
+			fmt.Fprintf(os.Stderr, "  %v (%v, %v): %v\n", r.Name, r.Provider, r.Region, r.Err)
+		}
+	}
+	return len(failed)
+}
+
+//Expands the parsed deployment file into one deployment per function, provider and region
+func getDeployments() []shared.Deployment {
+	var deployments []shared.Deployment
+
+	//Settings of a region override the ones of its provider, which override the ones of the function
 	mapDeploymentDtoToDeployment := func(dto shared.DeploymentDto, providerIndex int, regionIndex int) shared.Deployment {
-		handlerSplit := strings.Split(dto.Providers[providerIndex].Handler, ".")
-		if len(handlerSplit) != 2 {
-			fmt.Fprintln(os.Stderr, "Error: unable to parse function handler")
-			os.Exit(1)
+		provider := dto.Providers[providerIndex]
+		region := provider.Regions[regionIndex]
+		//The handler format is checked by Validate, commands not calling it do not use the handler
+		handlerFile, handlerFunction, _ := strings.Cut(provider.Handler, ".")
+
+		environment, secrets := getEnvironment(dto, provider)
+		triggers := dto.Triggers
+		if provider.Triggers != nil {
+			triggers = provider.Triggers
 		}
 
 		return shared.Deployment{
-			Archive:         dto.Archive,
-			Name:            dto.Name,
-			MemorySize:      dto.MemorySize,
-			Timeout:         dto.Timeout,
-			Runtime:         dto.Providers[providerIndex].Runtime,
-			Provider:        dto.Providers[providerIndex].Name,
-			HandlerFile:     handlerSplit[0],
-			HandlerFunction: handlerSplit[1],
-			Region:          dto.Providers[providerIndex].Regions[regionIndex],
+			Archive:             dto.Archive,
+			Name:                dto.Name,
+			MemorySize:          shared.Override(dto.MemorySize, provider.MemorySize, region.MemorySize),
+			Timeout:             shared.Override(dto.Timeout, provider.Timeout, region.Timeout),
+			Runtime:             shared.Override(provider.Runtime, region.Runtime),
+			Provider:            provider.Name,
+			HandlerFile:         handlerFile,
+			HandlerFunction:     handlerFunction,
+			Region:              region.Name,
+			MaxInstances:        shared.Override(dto.MaxInstances, provider.MaxInstances, region.MaxInstances),
+			MinInstances:        shared.Override(dto.MinInstances, provider.MinInstances, region.MinInstances),
+			ReservedConcurrency: shared.Override(dto.ReservedConcurrency, provider.ReservedConcurrency, region.ReservedConcurrency),
+			Environment:         environment,
+			Secrets:             secrets,
+			Triggers:            triggers,
+			Tags:                getTags(dto, provider),
+			Network:             provider.Network,
 		}
 	}
 
@@ -123,20 +315,52 @@ func Deploy() {
 			}
 		}
 	}
+	return deployments
+}
 
-	//TODO Upload Archive before goroutines
-
-	waitGroup.Add(len(deployments))
-	for _, deployment := range deployments {
-		err := shared.CheckDeployment(deployment)
-		shared.CheckErr(err, fmt.Sprintf("deployment check failed, Error: %v\n", err))
+//Merges the environment variables and secrets of the function with the ones of the provider, where the provider takes precedence
+func getEnvironment(dto shared.DeploymentDto, provider shared.Provider) (map[string]string, map[string]string) {
+	environment := make(map[string]string)
+	secrets := make(map[string]string)
+	for _, variables := range []map[string]string{dto.Environment, provider.Environment} {
+		for key, value := range variables {
+			environment[key] = value
+		}
+	}
+	for _, variables := range []map[string]string{dto.Secrets, provider.Secrets} {
+		for key, localVariable := range variables {
+			secrets[key] = localVariable
+		}
+	}
+	return environment, secrets
+}
 
-		if shared.ProviderAWS == deployment.Provider {
-			go my_aws.Deploy(&waitGroup, deployment, credentials)
+//Merges the tags of the function with the ones of the provider and adds the managed-by tag
+func getTags(dto shared.DeploymentDto, provider shared.Provider) map[string]string {
+	tags := make(map[string]string)
+	for _, t := range []map[string]string{dto.Tags, provider.Tags} {
+		for key, value := range t {
+			tags[key] = value
 		}
-		if shared.ProviderGoogle == deployment.Provider {
-			go google.Deploy(&waitGroup, deployment, credentials)
+	}
+	return shared.WithManagedBy(tags)
+}
+
+//Reads the values of all secrets from the local environment, only needed by commands that compare or set the environment
+func resolveSecrets(deployments []shared.Deployment) []shared.Deployment {
+	var missing []string
+	for i, d := range deployments {
+		resolved, missingVariables := d.ResolveSecrets()
+		for _, localVariable := range missingVariables {
+			if !shared.Contains(missing, localVariable) {
+				missing = append(missing, localVariable)
+			}
 		}
+		deployments[i] = resolved
 	}
-	waitGroup.Wait()
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		shared.CheckErr(fmt.Errorf("missing secrets"), fmt.Sprintf("unable to resolve secrets, local environment variables %v are not set", strings.Join(missing, ", ")))
+	}
+	return deployments
 }
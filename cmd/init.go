@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+var initName string
+var initArchive string
+var initMemory int32
+var initTimeout int32
+var initProviders []string
+var initHandler string
+var initRegions []string
+var initRuntimes []string
+var initForce bool
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Creates a deployment file and credential templates",
+	Long: `Generates a deployment file together with placeholder credential files for the chosen providers.
+Regions and runtimes either apply to all providers or to a single one when prefixed with the provider name:
+Ex.:
+	godeploy init
+	godeploy init --name hello --archive ./hello.zip -p AWS -p Google -r AWS=eu-central-1 -r Google=europe-west3
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().StringVar(&initName, "name", "myFunction", "Name of the function.")
+	initCmd.Flags().StringVar(&initArchive, "archive", "<ABSOLUTE_PATH_TO_ARCHIVE>", "Archive containing the code of the function.")
+	initCmd.Flags().Int32Var(&initMemory, "memory", 128, "Memory of the function in MB.")
+	initCmd.Flags().Int32Var(&initTimeout, "timeout", 60, "Timeout of the function in seconds.")
+	initCmd.Flags().StringSliceVarP(&initProviders, "provider", "p", []string{string(shared.ProviderAWS)}, "Providers the function should be deployed to (AWS|Azure|Google|OpenFaaS|OpenWhisk).")
+	initCmd.Flags().StringVar(&initHandler, "handler", "main.handler", "Handler of the function in the format <HANDLER_FILE>.<HANDLER_METHOD>.")
+	initCmd.Flags().StringSliceVarP(&initRegions, "region", "r", nil, "Regions the function should be deployed to, either <REGION> or <PROVIDER>=<REGION>.")
+	initCmd.Flags().StringSliceVar(&initRuntimes, "runtime", nil, "Runtime of the function, either <RUNTIME> or <PROVIDER>=<RUNTIME>.")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "If existing files should be overwritten.")
+}
+
+type initProvider struct {
+	Name    shared.ProviderName
+	Handler string
+	Regions []string
+	Runtime string
+	//Overrides the memory of the function if the provider does not support it, 0 if it is supported
+	Memory int32
+}
+
+func Init() {
+	files := make(map[string]string)
+	var providers []initProvider
+
+	for _, p := range initProviders {
+		name := shared.ProviderName(p)
+		provider := getProvider(name)
+
+		regions := providerValues(name, initRegions)
+		if len(regions) == 0 {
+			regions = []string{provider.DefaultRegion()}
+		}
+		runtime := provider.DefaultRuntime()
+		if runtimes := providerValues(name, initRuntimes); len(runtimes) > 0 {
+			runtime = runtimes[len(runtimes)-1]
+		}
+		providers = append(providers, initProvider{Name: name, Handler: initHandler, Regions: regions, Runtime: runtime, Memory: supportedMemory(provider.Limits(), initMemory)})
+
+		files[credentialsFileName(provider.CredentialsFile())] = provider.CredentialsTemplate()
+	}
+
+	files[deploymentFile] = renderTemplate(deploymentFileTemplate, map[string]interface{}{
+		"Name":      initName,
+		"Archive":   initArchive,
+		"Memory":    initMemory,
+		"Timeout":   initTimeout,
+		"Providers": providers,
+		"Valid":     shared.ValidProviders(),
+	})
+
+	if !initForce {
+		var existingFiles []string
+		for file := range files {
+			if _, err := os.Stat(file); err == nil {
+				existingFiles = append(existingFiles, file)
+			}
+		}
+		if len(existingFiles) > 0 {
+			sort.Strings(existingFiles)
+			shared.CheckErr(existingFiles, fmt.Sprintf("files %v already exist, use --force to overwrite them", existingFiles))
+		}
+	}
+
+	for file, content := range files {
+		err := os.WriteFile(file, []byte(content), 0600)
+		shared.CheckErr(err, fmt.Sprintf("unable to write file %v, Error: %v", file, err))
+		fmt.Printf("Created %v\n", file)
+	}
+}
+
+//Returns the values that apply to the given provider, values without a provider prefix apply to every provider
+func providerValues(provider shared.ProviderName, values []string) []string {
+	var res []string
+	for _, v := range values {
+		split := strings.SplitN(v, "=", 2)
+		if len(split) == 1 {
+			res = append(res, v)
+		} else if shared.ProviderName(split[0]) == provider {
+			res = append(res, split[1])
+		}
+	}
+	return res
+}
+
+//Returns the smallest memory size of the provider that is at least the given memory, or 0 if the memory is supported or the provider has no fixed sizes
+func supportedMemory(limits shared.ProviderLimits, memory int32) int32 {
+	if len(limits.MemorySizes) == 0 || shared.Contains(limits.MemorySizes, memory) {
+		return 0
+	}
+	for _, size := range limits.MemorySizes {
+		if size >= memory {
+			return size
+		}
+	}
+	return limits.MemorySizes[len(limits.MemorySizes)-1]
+}
+
+func credentialsFileName(credentialFile string) string {
+	return fmt.Sprintf("%v.%v", credentialFile, shared.DefaultFileExtension)
+}
+
+func renderTemplate(t *template.Template, data interface{}) string {
+	var sb strings.Builder
+	err := t.Execute(&sb, data)
+	shared.CheckErr(err, fmt.Sprintf("unable to render template %v, Error: %v", t.Name(), err))
+	return sb.String()
+}
+
+var deploymentFileTemplate = template.Must(template.New("deployment").Parse(`functions:
+  - archive: "{{ .Archive }}" # Corresponds to a zip file with all the code and its dependencies
+    name: "{{ .Name }}" # Name of the deployed function
+    memory: {{ .Memory }}
+    timeout: {{ .Timeout }}
+    providers:
+{{- range .Providers }}
+      - name: "{{ .Name }}" # Valid values are {{ $.Valid }}
+        handler: "{{ .Handler }}" # Follows the format <HANDLER_FILE>.<HANDLER_METHOD>
+        regions:
+{{- range .Regions }}
+          - "{{ . }}"
+{{- end }}
+        runtime: "{{ .Runtime }}"
+{{- if .Memory }}
+        memory: {{ .Memory }} # Closest memory size supported by {{ .Name }}
+{{- end }}
+{{- end }}
+`))
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"time"
+)
+
+var invokeProvider string
+var invokeRegion string
+var invokeData string
+var invokeDataFile string
+
+// invokeCmd represents the invoke command
+var invokeCmd = &cobra.Command{
+	Use:   "invoke <function name>",
+	Short: "Invokes a deployed function",
+	Long: `Calls a deployed function with a JSON payload and prints its response:
+Ex.:
+	godeploy invoke testPython -p AWS -r us-east-1 -d '{"name": "GoDeploy"}'
+	godeploy invoke testPython -p Google -r us-east1 --data-file payload.json
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Invoke(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(invokeCmd)
+
+	invokeCmd.Flags().StringVarP(&invokeProvider, "provider", "p", "", "Provider the function is deployed to (AWS|Azure|Google|OpenFaaS|OpenWhisk).")
+	invokeCmd.Flags().StringVarP(&invokeRegion, "region", "r", "", "Region the function is deployed to, defaults to the default region of the provider.")
+	invokeCmd.Flags().StringVarP(&invokeData, "data", "d", "", "JSON payload the function is called with.")
+	invokeCmd.Flags().StringVar(&invokeDataFile, "data-file", "", "File containing the JSON payload the function is called with.")
+	invokeCmd.MarkFlagRequired("provider")
+}
+
+func Invoke(name string) {
+	provider := shared.ProviderName(invokeProvider)
+	client := getProvider(provider)
+
+	region := invokeRegion
+	if region == "" {
+		region = client.DefaultRegion()
+	}
+
+	payload := []byte("{}")
+	if invokeData != "" && invokeDataFile != "" {
+		shared.CheckErr(invokeDataFile, "only one of --data and --data-file can be used")
+	}
+	if invokeData != "" {
+		payload = []byte(invokeData)
+	}
+	if invokeDataFile != "" {
+		payload = shared.ReadFile(invokeDataFile)
+	}
+	if !json.Valid(payload) {
+		shared.CheckErr(payload, "payload is not valid JSON")
+	}
+
+	loadProviderCredentials(provider)
+
+	start := time.Now()
+	invocation := client.InvokeFunction(shared.Config{Region: region, Credentials: credentials}, name, payload)
+	elapsed := time.Since(start)
+
+	fmt.Printf("Function: %v\n", name)
+	fmt.Printf("Provider: %v\n", provider)
+	fmt.Printf("Region:   %v\n", region)
+	fmt.Printf("Status:   %v\n", invocation.Status)
+	fmt.Printf("Duration: %s\n", elapsed)
+	if invocation.Error != "" {
+		fmt.Printf("Error:    %v\n", invocation.Error)
+	}
+	fmt.Printf("Response:\n%v\n", invocation.Body)
+
+	if invocation.Error != "" {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the deployed functions of your FaaS providers",
+	Long: `Prints every function deployed to the providers and regions used in the deployment file:
+Ex.:
+	godeploy list -f deployment.yaml
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		List()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
+
+func List() {
+	var deployedFunctions []shared.Function
+
+	checkConfig()
+
+	//Regions that can not be listed are reported, the functions of the others are still printed
+	failed := 0
+	for _, target := range getTargets(getDeployments()) {
+		client := getProvider(target.Provider)
+		functions, err := client.ListFunctions(shared.Config{Region: target.Region, Credentials: credentials})
+		if err != nil {
+			shared.Log(target.Provider, fmt.Sprintf("Unable to list functions in region %v, Error: %v", target.Region, err))
+			failed++
+			continue
+		}
+		deployedFunctions = append(deployedFunctions, functions...)
+	}
+
+	sort.Slice(deployedFunctions, func(i, j int) bool {
+		a, b := deployedFunctions[i], deployedFunctions[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.Name < b.Name
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPROVIDER\tREGION\tRUNTIME\tMEMORY (MB)\tTIMEOUT (S)")
+	for _, f := range deployedFunctions {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", f.Name, f.Provider, f.Region, f.Runtime, f.MemorySize, f.Timeout)
+	}
+	w.Flush()
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+//A single region of a provider
+type target struct {
+	Provider shared.ProviderName
+	Region   string
+}
+
+//Returns the distinct provider regions used by the given deployments
+func getTargets(deployments []shared.Deployment) []target {
+	var targets []target
+	for _, d := range deployments {
+		t := target{Provider: d.Provider, Region: d.Region}
+		if !shared.Contains(targets, t) {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
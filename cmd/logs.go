@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Interval in which new log entries are fetched when following the logs
+const logsFollowInterval = 5 * time.Second
+
+var logsSince time.Duration
+var logsFollow bool
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs <function name>",
+	Short: "Prints the logs of a deployed function",
+	Long: `Fetches the log entries of a function from every provider and region it is deployed to according to the deployment file
+and prints them as one stream ordered by their timestamp:
+Ex.:
+	godeploy logs testPython -f deployment.yaml --since 1h
+	godeploy logs testPython --follow
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Logs(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().DurationVar(&logsSince, "since", 10*time.Minute, "Only show log entries newer than the given duration, e.g. 30s, 5m or 2h.")
+	logsCmd.Flags().BoolVar(&logsFollow, "follow", false, "If new log entries should be printed continuously.")
+}
+
+func Logs(name string) {
+	checkConfig()
+
+	deployments := shared.Filter(getDeployments(), func(d shared.Deployment) bool { return d.Name == name })
+	if len(deployments) == 0 {
+		shared.CheckErr(name, fmt.Sprintf("function %v is not part of deployment file {%v}", name, deploymentFile))
+	}
+	for _, deployment := range deployments {
+		err := shared.CheckDeployment(deployment)
+		shared.CheckErr(err, fmt.Sprintf("deployment check failed, Error: %v\n", err))
+	}
+
+	since := time.Now().Add(-logsSince)
+	//Entries at the timestamp of the last fetch are fetched again, so already printed ones are skipped
+	printed := make(map[shared.LogEntry]bool)
+
+	for {
+		logs, failed := fetchLogs(deployments, since)
+		entries := shared.Filter(logs, func(e shared.LogEntry) bool { return !printed[e] })
+		for _, entry := range entries {
+			fmt.Printf("%v [%v %v] %v\n", entry.Timestamp.Format(time.RFC3339Nano), entry.Provider, entry.Region, strings.TrimRight(entry.Message, "\n"))
+		}
+		if !logsFollow {
+			if failed > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(entries) > 0 {
+			last := entries[len(entries)-1].Timestamp
+			if !last.Equal(since) {
+				printed = make(map[shared.LogEntry]bool)
+			}
+			since = last
+			for _, entry := range shared.Filter(entries, func(e shared.LogEntry) bool { return e.Timestamp.Equal(since) }) {
+				printed[entry] = true
+			}
+		}
+		time.Sleep(logsFollowInterval)
+	}
+}
+
+//Fetches the logs of all deployments in parallel and merges them into one list ordered by timestamp.
+//Targets whose logs can not be fetched are reported and counted, the logs of the others are still returned
+func fetchLogs(deployments []shared.Deployment, since time.Time) ([]shared.LogEntry, int) {
+	var waitGroup sync.WaitGroup
+	var entriesLock sync.Mutex
+	var entries []shared.LogEntry
+	failed := 0
+
+	waitGroup.Add(len(deployments))
+	for _, deployment := range deployments {
+		go func(d shared.Deployment) {
+			defer waitGroup.Done()
+			logs, err := getProvider(d.Provider).GetLogs(shared.Config{Region: d.Region, Credentials: credentials}, d.Name, since)
+
+			entriesLock.Lock()
+			defer entriesLock.Unlock()
+			if err != nil {
+				shared.Log(d.Provider, fmt.Sprintf("Unable to get logs of function %v in region %v, Error: %v", d.Name, d.Region, err))
+				failed++
+				return
+			}
+			entries = append(entries, logs...)
+		}(deployment)
+	}
+	waitGroup.Wait()
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, failed
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+)
+
+//Directory the archives built from source directories are written to
+var packageDirectory = shared.DefaultArchiveDirectory
+
+// packageCmd represents the package command
+var packageCmd = &cobra.Command{
+	Use:   "package",
+	Short: "Builds the archives of functions that define a source directory",
+	Long: `Zips the source directories of the deployment file into archives, the same source always results in a byte-identical archive:
+Ex.:
+	godeploy package -f deployment.yaml
+	godeploy package -f deployment.yaml -o build
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Package()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packageCmd)
+
+	packageCmd.Flags().StringVarP(&packageDirectory, "output", "o", shared.DefaultArchiveDirectory, "Directory the archives are written to.")
+}
+
+func Package() {
+	readDeploymentFile()
+
+	for _, dto := range packageSources() {
+		fmt.Printf("%v: %v (sha256 %v)\n", dto.Name, dto.Archive, shared.FileHash(dto.Archive, sha256.New()))
+	}
+}
+
+//Builds the archives of all functions with a source directory and returns these functions
+func packageSources() []shared.DeploymentDto {
+	var packaged []shared.DeploymentDto
+	for _, dto := range deploymentDtos {
+		if dto.Source == nil {
+			continue
+		}
+		shared.PackageSource(*dto.Source, dto.Archive)
+		packaged = append(packaged, dto)
+	}
+	return packaged
+}
+
+//Builds the archives of all functions with a source directory into a temporary directory, so commands that only compare
+//the archives with the deployed functions leave the archive directory untouched. The returned function removes the directory
+func packageSourcesTemporarily() func() {
+	directory, err := os.MkdirTemp("", "godeploy-")
+	shared.CheckErr(err, fmt.Sprintf("unable to create temporary directory for archives, Error: %v", err))
+
+	for i, dto := range deploymentDtos {
+		if dto.Source != nil {
+			deploymentDtos[i].Archive = shared.SourceArchivePath(directory, dto.Name)
+		}
+	}
+	packageSources()
+	return func() {
+		os.RemoveAll(directory)
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	planCreate    = "create"
+	planUpdate    = "update"
+	planUnchanged = "unchanged"
+	planError     = "error"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Shows what a deployment would change without deploying anything",
+	Long: `Compares the deployment file with the currently deployed functions and prints for every provider and region
+whether the function would be created, updated or left unchanged:
+Ex.:
+	godeploy plan -f deployment.yaml
+	godeploy deploy -f deployment.yaml --dry-run
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Plan()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
+
+func Plan() {
+	Validate()
+	checkConfig()
+	removeTemporaryArchives := packageSourcesTemporarily()
+	deployments := resolveSecrets(getDeployments())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tFUNCTION\tPROVIDER\tREGION\tCHANGES")
+	failed := 0
+	for _, deployment := range deployments {
+		err := shared.CheckDeployment(deployment)
+		shared.CheckErr(err, fmt.Sprintf("deployment check failed, Error: %v\n", err))
+
+		//Targets whose function can not be fetched show the error instead of their changes
+		action, changes, err := planDeployment(deployment)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", planError, deployment.Name, deployment.Provider, deployment.Region, err)
+			continue
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", action, deployment.Name, deployment.Provider, deployment.Region, formatChanges(changes))
+	}
+	w.Flush()
+	removeTemporaryArchives()
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+//Returns the action a deployment would perform together with the configuration changes it would apply
+func planDeployment(d shared.Deployment) (string, []shared.FieldChange, error) {
+	client := getProvider(d.Provider)
+	desired := client.DesiredFunction(d)
+
+	current, err := client.GetFunction(shared.Config{Region: d.Region, Credentials: credentials}, d.Name)
+	if err != nil {
+		return "", nil, err
+	}
+	if current == nil {
+		return planCreate, nil, nil
+	}
+
+	changes := shared.CompareFunctions(*current, desired)
+	if len(changes) == 0 {
+		return planUnchanged, nil, nil
+	}
+	return planUpdate, changes, nil
+}
+
+func formatChanges(changes []shared.FieldChange) string {
+	return strings.Join(shared.Map(changes, func(c shared.FieldChange) string {
+		if c.Field == "code" {
+			return "code changed"
+		}
+		if c.Field == "environment" {
+			return fmt.Sprintf("environment changed (%v)", c.Desired)
+		}
+		return fmt.Sprintf("%v: %v -> %v", c.Field, c.Current, c.Desired)
+	}), ", ")
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"sync"
+)
+
+var removeArchives bool
+
+// removeCmd represents the remove command
+var removeCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes your deployed functions from the different FaaS providers",
+	Long: `Deletes every function described in the deployment file from all providers and regions it was deployed to:
+Ex.:
+	godeploy remove -f deployment.yaml
+	godeploy remove -f deployment.yaml --archives
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Remove()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(removeCmd)
+
+	removeCmd.Flags().BoolVar(&removeArchives, "archives", false, "If the uploaded archives should also be deleted from the deployment buckets.")
+}
+
+func Remove() {
+	var waitGroup sync.WaitGroup
+
+	checkConfig()
+	deployments := getDeployments()
+
+	for _, deployment := range deployments {
+		err := shared.CheckDeployment(deployment)
+		shared.CheckErr(err, fmt.Sprintf("deployment check failed, Error: %v\n", err))
+	}
+
+	//Targets are removed independently, a failed target does not stop the others
+	results := make([]shared.DeploymentResult, len(deployments))
+	waitGroup.Add(len(deployments))
+	for i, deployment := range deployments {
+		go func(i int, d shared.Deployment) {
+			defer waitGroup.Done()
+			results[i] = getProvider(d.Provider).DeleteFunction(shared.Config{Region: d.Region, Credentials: credentials}, d, removeArchives)
+		}(i, deployment)
+	}
+	waitGroup.Wait()
+
+	if printDeploymentResults(results) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"sync"
+)
+
+var rollbackVersion string
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <function name>",
+	Short: "Restores a previous version of a deployed function",
+	Long: `Restores the code and configuration of the previous (or the given) version of a function
+in every provider and region it is deployed to according to the deployment file:
+Ex.:
+	godeploy rollback testPython -f deployment.yaml
+	godeploy rollback testPython --to 20220301-120000-000000
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Rollback(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().StringVar(&rollbackVersion, "to", "", "Version to roll back to, defaults to the version deployed before the current one.")
+}
+
+func Rollback(name string) {
+	var waitGroup sync.WaitGroup
+
+	checkConfig()
+	deployments := shared.Filter(getDeployments(), func(d shared.Deployment) bool { return d.Name == name })
+	if len(deployments) == 0 {
+		shared.CheckErr(name, fmt.Sprintf("function %v is not part of deployment file {%v}", name, deploymentFile))
+	}
+
+	for _, deployment := range deployments {
+		err := shared.CheckDeployment(deployment)
+		shared.CheckErr(err, fmt.Sprintf("deployment check failed, Error: %v\n", err))
+	}
+
+	//Targets are rolled back independently, a failed target does not stop the others
+	results := make([]shared.DeploymentResult, len(deployments))
+	waitGroup.Add(len(deployments))
+	for i, deployment := range deployments {
+		go func(i int, d shared.Deployment) {
+			defer waitGroup.Done()
+			results[i] = getProvider(d.Provider).RollbackFunction(shared.Config{Region: d.Region, Credentials: credentials}, d, rollbackVersion)
+		}(i, deployment)
+	}
+	waitGroup.Wait()
+
+	if printDeploymentResults(results) > 0 {
+		os.Exit(1)
+	}
+}
@@ -37,6 +37,10 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	//rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.GoDeploy.yaml)")
+	//The deployment file, its variables and stage are shared by all commands reading the deployment file
+	rootCmd.PersistentFlags().StringVarP(&deploymentFile, "file", "f", "deployment.yaml", "If the non default deployment file should be used, or created by init.")
+	rootCmd.PersistentFlags().StringArrayVar(&deploymentVariables, "var", nil, "Variables of the deployment file given as <NAME>=<VALUE>, can be repeated.")
+	rootCmd.PersistentFlags().StringVar(&deploymentStage, "stage", "", "Stage of the deployment file whose settings should be applied.")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -48,7 +52,7 @@ func initConfig() {
 	// Find working directory.
 	wd, err := os.Getwd()
 	cobra.CheckErr(err)
-	//Set type for all configuration files to .yaml
+	//Credentials files and the deployment file, after it is converted and interpolated, are read as .yaml
 	viper.AddConfigPath(wd)
 	viper.SetConfigType(shared.DefaultFileExtension)
 
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+const (
+	statusOK      = "OK"
+	statusMissing = "MISSING"
+	statusFailed  = "FAILED"
+	statusDrifted = "DRIFTED"
+	statusError   = "ERROR"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Shows whether the deployed functions match the deployment file",
+	Long: `Fetches the state and configuration of every function in every provider and region of the deployment file
+and prints a matrix highlighting functions that are missing, failed or drifted from the deployment file.
+Exits with a non-zero exit code if any function is not OK:
+Ex.:
+	godeploy status -f deployment.yaml
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Status()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+//Status of a single deployment target
+type targetStatus struct {
+	Deployment shared.Deployment
+	Function   *shared.Function
+	Status     string
+	Changes    []shared.FieldChange
+	//Error that prevented fetching the function, the other targets are still checked
+	Err error
+}
+
+func Status() {
+	Validate()
+	checkConfig()
+	removeTemporaryArchives := packageSourcesTemporarily()
+	deployments := resolveSecrets(getDeployments())
+
+	var waitGroup sync.WaitGroup
+	statuses := make([]targetStatus, len(deployments))
+
+	waitGroup.Add(len(deployments))
+	for i, deployment := range deployments {
+		err := shared.CheckDeployment(deployment)
+		shared.CheckErr(err, fmt.Sprintf("deployment check failed, Error: %v\n", err))
+
+		go func(i int, d shared.Deployment) {
+			defer waitGroup.Done()
+			statuses[i] = getTargetStatus(d)
+		}(i, deployment)
+	}
+	waitGroup.Wait()
+	removeTemporaryArchives()
+
+	printStatusMatrix(statuses)
+	fmt.Println()
+	printStatusDetails(statuses)
+
+	if shared.Any(statuses, func(s targetStatus) bool { return s.Status != statusOK }) {
+		os.Exit(1)
+	}
+}
+
+func getTargetStatus(d shared.Deployment) targetStatus {
+	client := getProvider(d.Provider)
+	current, err := client.GetFunction(shared.Config{Region: d.Region, Credentials: credentials}, d.Name)
+
+	status := targetStatus{Deployment: d, Function: current, Status: statusOK}
+	if err != nil {
+		shared.Log(d.Provider, fmt.Sprintf("Unable to get function %v in region %v, Error: %v", d.Name, d.Region, err))
+		status.Status = statusError
+		status.Err = err
+		return status
+	}
+	if current == nil {
+		status.Status = statusMissing
+		return status
+	}
+
+	status.Changes = shared.CompareFunctions(*current, client.DesiredFunction(d))
+	if current.Failed {
+		status.Status = statusFailed
+	} else if len(status.Changes) > 0 {
+		status.Status = statusDrifted
+	}
+	return status
+}
+
+//Prints one row per function and one column per provider region, targets not used by a function are marked with -
+func printStatusMatrix(statuses []targetStatus) {
+	var names []string
+	var columns []target
+	cells := make(map[string]map[target]string)
+
+	for _, s := range statuses {
+		t := target{Provider: s.Deployment.Provider, Region: s.Deployment.Region}
+		if !shared.Contains(columns, t) {
+			columns = append(columns, t)
+		}
+		if cells[s.Deployment.Name] == nil {
+			names = append(names, s.Deployment.Name)
+			cells[s.Deployment.Name] = make(map[target]string)
+		}
+		cells[s.Deployment.Name][t] = s.Status
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "FUNCTION\t%v\n", strings.Join(shared.Map(columns, func(t target) string { return fmt.Sprintf("%v %v", t.Provider, t.Region) }), "\t"))
+	for _, name := range names {
+		row := shared.Map(columns, func(t target) string {
+			if status, ok := cells[name][t]; ok {
+				return status
+			}
+			return "-"
+		})
+		fmt.Fprintf(w, "%v\t%v\n", name, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+//Targets whose function could not be fetched show the error instead of their drift
+func printStatusDetails(statuses []targetStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "FUNCTION\tPROVIDER\tREGION\tSTATUS\tSTATE\tDRIFT")
+	for _, s := range statuses {
+		state := "-"
+		if s.Function != nil {
+			state = s.Function.State
+		}
+		drift := formatChanges(s.Changes)
+		if s.Err != nil {
+			drift = s.Err.Error()
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", s.Deployment.Name, s.Deployment.Provider, s.Deployment.Region, s.Status, state, drift)
+	}
+	w.Flush()
+}
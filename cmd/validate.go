@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"godeploy/shared"
+	"os"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates your deployment file",
+	Long: `Checks the whole deployment file for unknown keys, unsupported providers, runtimes and regions, invalid handlers
+and memory or timeout values outside of the limits of a provider, without contacting any provider:
+Ex.:
+	godeploy validate -f deployment.yaml
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Validate()
+		fmt.Printf("%v is valid\n", deploymentFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+//Validates the deployment file and exits after printing every problem found
+func Validate() {
+	exitOnValidationErrors(shared.ValidateDeploymentFile(getDeploymentFilePath(), getDeploymentVariables(), deploymentStage))
+}
+
+func exitOnValidationErrors(errors []shared.ValidationError) {
+	if len(errors) == 0 {
+		return
+	}
+
+	for _, err := range errors {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	problems := "problems"
+	if len(errors) == 1 {
+		problems = "problem"
+	}
+	fmt.Fprintf(os.Stderr, "found %v %v in deployment file {%v}\n", len(errors), problems, deploymentFile)
+	os.Exit(1)
+}
@@ -0,0 +1,219 @@
+package google
+
+import (
+	functions "cloud.google.com/go/functions/apiv1"
+	"cloud.google.com/go/storage"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+	"google.golang.org/api/iterator"
+	logging "google.golang.org/api/logging/v2"
+	"google.golang.org/api/option"
+	functions2 "google.golang.org/genproto/googleapis/cloud/functions/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"time"
+)
+
+//Implementation of shared.Client for Google Cloud Functions
+type Client struct{}
+
+//Creating a function that is already deployed updates it, like on the other providers
+func (Client) CreateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d, "")
+}
+
+func (Client) UpdateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d, shared.ActionUpdate)
+}
+
+//Lists the functions of the given region, or of all regions if no region is given
+func (Client) ListFunctions(cfg shared.Config) ([]shared.Function, error) {
+	functionsClient, err := functions.NewCloudFunctionsClient(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Google cloud functions client, Error: %v", err)
+	}
+	defer functionsClient.Close()
+
+	region := cfg.Region
+	if region == "" {
+		region = "-"
+	}
+
+	var f []shared.Function
+	listFunctions := functionsClient.ListFunctions(context.Background(), &functions2.ListFunctionsRequest{Parent: fmt.Sprintf("projects/%v/locations/%v", viper.GetString(shared.GoogleProjectID), region)})
+	for {
+		item, err := listFunctions.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to list deployed functions in region %v, Error: %v", region, err)
+		}
+		f = append(f, mapCloudFunction(item))
+	}
+	return f, nil
+}
+
+func (Client) GetFunction(cfg shared.Config, name string) (*shared.Function, error) {
+	functionsClient, err := functions.NewCloudFunctionsClient(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Google cloud functions client, Error: %v", err)
+	}
+	defer functionsClient.Close()
+
+	functionName := fmt.Sprintf("projects/%v/locations/%v/functions/%v", viper.GetString(shared.GoogleProjectID), cfg.Region, name)
+	cloudFunction, err := functionsClient.GetFunction(context.Background(), &functions2.GetFunctionRequest{Name: functionName})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get function %v, Error: %v", functionName, err)
+	}
+
+	f := mapCloudFunction(cloudFunction)
+	f.CodeHash, err = getArchiveHash(cfg, cloudFunction.GetSourceArchiveUrl())
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (Client) DesiredFunction(d shared.Deployment) shared.Function {
+	return shared.Function{
+		Name:         d.Name,
+		Provider:     shared.ProviderGoogle,
+		Region:       d.Region,
+		Runtime:      d.Runtime,
+		Handler:      d.HandlerFunction,
+		MemorySize:   d.MemorySize,
+		Timeout:      d.Timeout,
+		Environment:  d.Environment,
+		MaxInstances: getMaxInstances(d),
+		MinInstances: d.MinInstances,
+		//Cloud storage reports the base64 encoded MD5 hash of its objects
+		CodeHash: shared.FileHash(d.Archive, md5.New()),
+	}
+}
+
+func (Client) InvokeFunction(cfg shared.Config, name string, payload []byte) shared.Invocation {
+	functionsClient, err := functions.NewCloudFunctionsClient(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to create Google cloud functions client, Error: %v", err)}
+	}
+	defer functionsClient.Close()
+
+	functionName := fmt.Sprintf("projects/%v/locations/%v/functions/%v", viper.GetString(shared.GoogleProjectID), cfg.Region, name)
+	response, err := functionsClient.CallFunction(context.Background(), &functions2.CallFunctionRequest{Name: functionName, Data: string(payload)})
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to invoke function %v, Error: %v", functionName, err)}
+	}
+
+	invocation := shared.Invocation{
+		Status: "OK",
+		Body:   response.Result,
+		Error:  response.Error,
+	}
+	if response.Error != "" {
+		invocation.Status = "FAILED"
+	}
+	return invocation
+}
+
+func (Client) GetLogs(cfg shared.Config, name string, since time.Time) ([]shared.LogEntry, error) {
+	loggingService, err := logging.NewService(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Google logging client, Error: %v", err)
+	}
+
+	filter := fmt.Sprintf(`resource.type="cloud_function" AND resource.labels.function_name="%v" AND resource.labels.region="%v" AND timestamp>="%v"`,
+		name, cfg.Region, since.UTC().Format(time.RFC3339Nano))
+	request := &logging.ListLogEntriesRequest{
+		ResourceNames: []string{fmt.Sprintf("projects/%v", viper.GetString(shared.GoogleProjectID))},
+		Filter:        filter,
+		OrderBy:       "timestamp asc",
+	}
+
+	var entries []shared.LogEntry
+	err = loggingService.Entries.List(request).Pages(context.Background(), func(response *logging.ListLogEntriesResponse) error {
+		for _, entry := range response.Entries {
+			timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+			if err != nil {
+				continue
+			}
+			message := entry.TextPayload
+			if message == "" {
+				message = string(entry.JsonPayload)
+			}
+			entries = append(entries, shared.LogEntry{
+				Timestamp: timestamp,
+				Provider:  shared.ProviderGoogle,
+				Region:    cfg.Region,
+				Message:   message,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get logs of function %v in region %v, Error: %v", name, cfg.Region, err)
+	}
+	return entries, nil
+}
+
+//Returns the hash of the archive a function was deployed from, or an empty string if it cannot be determined
+func getArchiveHash(cfg shared.Config, archiveURL string) (string, error) {
+	bucket, key := shared.ParseStorageObjectURI(archiveURL)
+	if bucket == "" && key == "" {
+		return "", nil
+	}
+
+	storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return "", fmt.Errorf("unable to create Google storage client, Error: %v", err)
+	}
+	defer storageClient.Close()
+
+	//The hash of an archive that was deleted in the meantime is unknown
+	attrs, err := storageClient.Bucket(bucket).Object(key).Attrs(context.Background())
+	if err == storage.ErrObjectNotExist {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to get archive %v, Error: %v", archiveURL, err)
+	}
+	return base64.StdEncoding.EncodeToString(attrs.MD5), nil
+}
+
+func mapCloudFunction(c *functions2.CloudFunction) shared.Function {
+	//Names follow the format projects/<PROJECT_ID>/locations/<REGION>/functions/<NAME>
+	nameSplit := strings.Split(c.Name, "/")
+
+	return shared.Function{
+		Name:         nameSplit[len(nameSplit)-1],
+		Provider:     shared.ProviderGoogle,
+		Region:       parseRegion(c.Name),
+		Runtime:      c.Runtime,
+		Handler:      c.EntryPoint,
+		MemorySize:   c.AvailableMemoryMb,
+		Timeout:      int32(c.GetTimeout().GetSeconds()),
+		State:        c.Status.String(),
+		Failed:       c.Status == functions2.CloudFunctionStatus_OFFLINE || c.Status == functions2.CloudFunctionStatus_UNKNOWN,
+		Environment:  c.EnvironmentVariables,
+		MaxInstances: c.MaxInstances,
+		MinInstances: c.MinInstances,
+	}
+}
+
+func parseRegion(functionName string) string {
+	nameSplit := strings.Split(functionName, "/")
+	for i := 0; i < len(nameSplit)-1; i++ {
+		if nameSplit[i] == "locations" {
+			return nameSplit[i+1]
+		}
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	functions "cloud.google.com/go/functions/apiv1"
 	"cloud.google.com/go/storage"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/spf13/viper"
@@ -14,99 +15,186 @@ import (
 	functions2 "google.golang.org/genproto/googleapis/cloud/functions/v1"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"io"
-	"log"
 	"os"
 	"strings"
 	"sync"
 	"time"
 )
 
+//Archives referenced via an S3 URI are only copied once per object key
 var copyArchiveLock sync.Mutex
-var deploymentKey string
+var copiedArchives = make(map[string]bool)
 
 var createBucketLock sync.Mutex
 
-var credHolder shared.CredentialsHolder
-var deployedFunctions []string
-
-func Deploy(waitGroup *sync.WaitGroup, de shared.Deployment, credentialsHolder shared.CredentialsHolder) {
-	defer waitGroup.Done()
-	credHolder = credentialsHolder
+//Uploads the archive to the deployment bucket, the deployment references the uploaded archive by its gs:// URI
+func (Client) UploadArchive(cfg shared.Config, de shared.Deployment) (shared.Deployment, error) {
+	if de.Version == "" {
+		de.Version = shared.NewVersion()
+	}
 
-	storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(credentialsHolder.GoogleCredentials))
-	shared.CheckErr(err, fmt.Sprintf("unable to create Google storage client, Error: %v", err))
+	storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return de, fmt.Errorf("unable to create Google storage client, Error: %v", err)
+	}
 	defer storageClient.Close()
 
-	functionsClient, err := functions.NewCloudFunctionsClient(context.Background(), option.WithCredentials(credentialsHolder.GoogleCredentials))
-	shared.CheckErr(err, fmt.Sprintf("unable to create Google cloud functions client, Error: %v", err))
-	defer functionsClient.Close()
-
-	deployedFunctions = getDeployedFunctions(functionsClient)
-	// shared.Log(shared.ProviderGoogle, fmt.Sprintf("Deployed functions: %v", deployedFunctions))
-
 	start := time.Now()
-	//Check if archive is already present in the storage
-	archiveURL := uploadArchive(de.Archive, de.Name, storageClient)
+	archiveURL, err := uploadArchive(cfg.Credentials, de.Archive, shared.ArchiveKey(de.Name, de.Version), de.Tags, storageClient)
+	if err != nil {
+		return de, err
+	}
 	elapsed := time.Since(start)
 
 	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Location of archive: %v, region: %v, upload took %s", archiveURL, de.Region, elapsed))
 	de.Archive = archiveURL
+	return de, nil
+}
 
-	if shared.Any(deployedFunctions, func(s string) bool { return strings.Contains(s, de.Region) && strings.Contains(s, de.Name) }) {
-		updateFunction(de, functionsClient)
-	} else {
-		createFunction(de, functionsClient)
+//Uploads the archive and creates or updates the function depending on the given action,
+//if no action is given the function is either created or updated depending on whether it is already deployed
+func deploy(cfg shared.Config, de shared.Deployment, action shared.DeploymentAction) shared.DeploymentResult {
+	start := time.Now()
+	if de.Version == "" {
+		de.Version = shared.NewVersion()
+	}
+	result := shared.NewDeploymentResult(de)
+
+	storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to create Google storage client, Error: %v", err), start)
+	}
+	defer storageClient.Close()
+
+	functionsClient, err := functions.NewCloudFunctionsClient(context.Background(), option.WithCredentials(cfg.Credentials.GoogleCredentials))
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to create Google cloud functions client, Error: %v", err), start)
+	}
+	defer functionsClient.Close()
+
+	//Archives that have already been uploaded are referenced by their gs:// URI and not uploaded again
+	if !shared.IsGoogleObjectURI(de.Archive) {
+		if de, err = (Client{}).UploadArchive(cfg, de); err != nil {
+			return result.Fail(err, start)
+		}
+	}
+
+	if action == "" {
+		deployedFunctions, err := getDeployedFunctions(functionsClient)
+		if err != nil {
+			return result.Fail(err, start)
+		}
+		// shared.Log(shared.ProviderGoogle, fmt.Sprintf("Deployed functions: %v", deployedFunctions))
+
+		action = shared.ActionCreate
+		if shared.Any(deployedFunctions, func(s string) bool { return strings.Contains(s, de.Region) && strings.Contains(s, de.Name) }) {
+			action = shared.ActionUpdate
+		}
 	}
+	result.Action = action
 
+	operation := createFunction
+	if action == shared.ActionUpdate {
+		operation = updateFunction
+	}
+	//Functions are identified by their resource name, e.g. projects/<PROJECT>/locations/<REGION>/functions/<NAME>
+	if result.Function, err = operation(cfg.Credentials, de, functionsClient); err != nil {
+		return result.Fail(err, start)
+	}
+	if err = recordDeployment(de, storageClient); err != nil {
+		return result.Fail(err, start)
+	}
+	result.Duration = time.Since(start)
+	return result
 }
 
-func uploadArchive(archiveURL string, name string, storageClient *storage.Client) string {
-	if shared.IsGoogleObjectURI(archiveURL) {
-		return archiveURL
-	} else if shared.IsAWSObjectURI(archiveURL) {
+func uploadArchive(c shared.CredentialsHolder, archiveURL string, objectKey string, metadata map[string]string, storageClient *storage.Client) (string, error) {
+	if shared.IsGoogleObjectURI(archiveURL) || shared.IsAWSObjectURI(archiveURL) {
 		copyArchiveLock.Lock()
-		if deploymentKey == "" {
-			deploymentKey = copyFromAWSToGoogle(archiveURL, storageClient)
+		defer copyArchiveLock.Unlock()
+		if !copiedArchives[objectKey] {
+			var err error
+			if shared.IsGoogleObjectURI(archiveURL) {
+				err = copyWithinGoogle(archiveURL, objectKey, metadata, storageClient)
+			} else {
+				err = copyFromAWSToGoogle(c, archiveURL, objectKey, metadata, storageClient)
+			}
+			if err != nil {
+				return "", err
+			}
+			copiedArchives[objectKey] = true
 		}
-		copyArchiveLock.Unlock()
 
-		return buildGoogleUtilURL(shared.ArchiveBucketName, deploymentKey)
+		return buildGoogleUtilURL(shared.ArchiveBucketName, objectKey), nil
+	}
+
+	bucketHandle, err := getArchiveBucket(storageClient)
+	if err != nil {
+		return "", err
 	}
 
+	f, err := os.Open(archiveURL)
+	if err != nil {
+		return "", fmt.Errorf("os.Open: %v, Error: %v", archiveURL, err)
+	}
+	defer f.Close()
+
+	writer := bucketHandle.Object(objectKey).NewWriter(context.Background())
+	writer.Metadata = metadata
+	if _, err = io.Copy(writer, f); err != nil {
+		writer.Close()
+		return "", fmt.Errorf("io.Copy: %v", err)
+	}
+	if err = writer.Close(); err != nil {
+		return "", fmt.Errorf("unable to upload archive to bucket on GCP, Error: %v", err)
+	}
+
+	return buildGoogleUtilURL(shared.ArchiveBucketName, objectKey), nil
+}
+
+//Returns the handle of the archive bucket, the bucket is created if it doesn't exist
+func getArchiveBucket(storageClient *storage.Client) (*storage.BucketHandle, error) {
 	createBucketLock.Lock()
-	//Create bucket if it doesn't exist
-	bucketHandle := storageClient.Bucket(shared.ArchiveBucketName)
+	defer createBucketLock.Unlock()
 
+	bucketHandle := storageClient.Bucket(shared.ArchiveBucketName)
 	_, err := bucketHandle.Attrs(context.Background())
-	if err != nil {
-		if ((strings.Contains(err.Error(), "bucket doesn't exist")) || (strings.Contains(err.Error(), "not exist"))) {
-			shared.Log(shared.ProviderGoogle, fmt.Sprintf("Bucket %v doesn't exist, creating new one", shared.ArchiveBucketName))
-
-			err = bucketHandle.Create(context.Background(), viper.GetString(shared.GoogleProjectID), nil)
-			createBucketLock.Unlock()
-			shared.CheckErr(err, fmt.Sprintf("unable to create bucket on GCP, Error %v", err))
-		} else {
-			createBucketLock.Unlock()
-			shared.CheckErr(err, fmt.Sprintf("unable to access bucket on GCP, Error %v", err))
-		}
-	} else {
-		createBucketLock.Unlock()
+	if err == nil {
+		return bucketHandle, nil
+	}
+	if !strings.Contains(err.Error(), "bucket doesn't exist") && !strings.Contains(err.Error(), "not exist") {
+		return nil, fmt.Errorf("unable to access bucket on GCP, Error %v", err)
 	}
 
-	writer := bucketHandle.Object(name).NewWriter(context.Background())
-	defer writer.Close()
+	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Bucket %v doesn't exist, creating new one", shared.ArchiveBucketName))
+	if err = bucketHandle.Create(context.Background(), viper.GetString(shared.GoogleProjectID), nil); err != nil {
+		return nil, fmt.Errorf("unable to create bucket on GCP, Error %v", err)
+	}
+	return bucketHandle, nil
+}
 
-	f, err := os.Open(archiveURL)
-	defer f.Close()
-	shared.CheckErr(err, fmt.Sprintf("os.Open: %v, Error: %v", archiveURL, err))
+//Copies an archive that is already stored in Cloud Storage to the versioned key in the archive bucket
+func copyWithinGoogle(srcURL string, targetKey string, metadata map[string]string, storageClient *storage.Client) error {
+	bucket, key := shared.ParseStorageObjectURI(srcURL)
+	if bucket == "" && key == "" {
+		return fmt.Errorf("unable to parse Cloud Storage object URI {%v}", srcURL)
+	}
 
-	_, err = io.Copy(writer, f)
-	shared.CheckErr(err, fmt.Sprintf("io.Copy: %v", err))
+	bucketHandle, err := getArchiveBucket(storageClient)
+	if err != nil {
+		return err
+	}
 
-	return buildGoogleUtilURL(shared.ArchiveBucketName, name)
+	copier := bucketHandle.Object(targetKey).CopierFrom(storageClient.Bucket(bucket).Object(key))
+	//The metadata of the source object is replaced by the tags of the function
+	copier.Metadata = metadata
+	if _, err = copier.Run(context.Background()); err != nil {
+		return fmt.Errorf("unable to copy object %v in Cloud Storage, Error: %v", srcURL, err)
+	}
+	return nil
 }
 
-func createFunction(d shared.Deployment, functionsClient *functions.CloudFunctionsClient) {
+func createFunction(c shared.CredentialsHolder, d shared.Deployment, functionsClient *functions.CloudFunctionsClient) (string, error) {
 	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Started creating function %v in region %v with %v MB memory", d.Name, d.Region, d.MemorySize))
 
 	start := time.Now()
@@ -119,15 +207,23 @@ func createFunction(d shared.Deployment, functionsClient *functions.CloudFunctio
 
 	functionName := fmt.Sprintf("projects/%v/locations/%v/functions/%v", projectID, d.Region, d.Name)
 	function := functions2.CloudFunction{
-		Name:              functionName,
-		SourceCode:        sourceArchive,
-		Trigger:           &functions2.CloudFunction_HttpsTrigger{},
-		Status:            0,
-		EntryPoint:        d.HandlerFunction,
-		Runtime:           d.Runtime,
-		Timeout:           timeout,
-		AvailableMemoryMb: d.MemorySize,
-		MaxInstances:      shared.DefaultMaxFunctionInstances,
+		Name:                       functionName,
+		SourceCode:                 sourceArchive,
+		Status:                     0,
+		EntryPoint:                 d.HandlerFunction,
+		Runtime:                    d.Runtime,
+		Timeout:                    timeout,
+		AvailableMemoryMb:          d.MemorySize,
+		MaxInstances:               getMaxInstances(d),
+		MinInstances:               d.MinInstances,
+		Labels:                     getLabels(d),
+		EnvironmentVariables:       d.Environment,
+		VpcConnector:               getVpcConnector(d),
+		VpcConnectorEgressSettings: getEgressSettings(d),
+		IngressSettings:            getIngressSettings(d),
+	}
+	if err := setTrigger(c, &function, d); err != nil {
+		return "", err
 	}
 	location := fmt.Sprintf("projects/%v/locations/%v", projectID, d.Region)
 	request := functions2.CreateFunctionRequest{
@@ -135,17 +231,25 @@ func createFunction(d shared.Deployment, functionsClient *functions.CloudFunctio
 		Function: &function,
 	}
 	createFunctionOperation, err := functionsClient.CreateFunction(context.Background(), &request)
-	shared.CheckErr(err, fmt.Sprintf("unable to create function, Error: %v", err))
+	if err != nil {
+		return "", fmt.Errorf("unable to create function, Error: %v", err)
+	}
 
 	poll, err := createFunctionOperation.Wait(context.Background())
-	shared.CheckErr(err, fmt.Sprintf("unable to wait for function deployment, Error: %v", err))
+	if err != nil {
+		return functionName, fmt.Errorf("unable to wait for function deployment, Error: %v", err)
+	}
+	if err = reconcileTriggers(c, d, poll, functionsClient); err != nil {
+		return poll.Name, err
+	}
 	
 	elapsed := time.Since(start)
 
 	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Finished creating function %v in region %v with %v MB memory, took %s", poll.Name, d.Region, d.MemorySize, elapsed))
+	return poll.Name, nil
 }
 
-func updateFunction(d shared.Deployment, functionsClient *functions.CloudFunctionsClient) {
+func updateFunction(c shared.CredentialsHolder, d shared.Deployment, functionsClient *functions.CloudFunctionsClient) (string, error) {
 	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Started updating function %v in region %v with %v MB memory", d.Name, d.Region, d.MemorySize))
 
 	sourceArchive := &functions2.CloudFunction_SourceArchiveUrl{SourceArchiveUrl: d.Archive}
@@ -155,29 +259,71 @@ func updateFunction(d shared.Deployment, functionsClient *functions.CloudFunctio
 	}
 	functionName := fmt.Sprintf("projects/%v/locations/%v/functions/%v", viper.GetString(shared.GoogleProjectID), d.Region, d.Name)
 	function := &functions2.CloudFunction{
-		Name:              functionName,
-		SourceCode:        sourceArchive,
-		Trigger:           &functions2.CloudFunction_HttpsTrigger{},
-		Status:            0,
-		EntryPoint:        d.HandlerFunction,
-		Runtime:           d.Runtime,
-		Timeout:           timeout,
-		AvailableMemoryMb: d.MemorySize,
-		MaxInstances:      shared.DefaultMaxFunctionInstances,
+		Name:                       functionName,
+		SourceCode:                 sourceArchive,
+		Status:                     0,
+		EntryPoint:                 d.HandlerFunction,
+		Runtime:                    d.Runtime,
+		Timeout:                    timeout,
+		AvailableMemoryMb:          d.MemorySize,
+		MaxInstances:               getMaxInstances(d),
+		MinInstances:               d.MinInstances,
+		Labels:                     getLabels(d),
+		EnvironmentVariables:       d.Environment,
+		VpcConnector:               getVpcConnector(d),
+		VpcConnectorEgressSettings: getEgressSettings(d),
+		IngressSettings:            getIngressSettings(d),
+	}
+	if err := setTrigger(c, function, d); err != nil {
+		return "", err
 	}
 	updateFunctionRequest := &functions2.UpdateFunctionRequest{
 		Function: function,
 	}
 	updateFunctionOperation, err := functionsClient.UpdateFunction(context.Background(), updateFunctionRequest)
-	shared.CheckErr(err, fmt.Sprintf("unable to update updateFunctionOperation, Error: %v", err))
+	if err != nil {
+		return functionName, fmt.Errorf("unable to update updateFunctionOperation, Error: %v", err)
+	}
 
 	poll, err := updateFunctionOperation.Wait(context.Background())
-	shared.CheckErr(err, fmt.Sprintf("unable to wait for function deployment, Error: %v", err))
+	if err != nil {
+		return functionName, fmt.Errorf("unable to wait for function deployment, Error: %v", err)
+	}
+	if err = reconcileTriggers(c, d, poll, functionsClient); err != nil {
+		return poll.Name, err
+	}
 
 	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Finished updating function %v in region %v with %v MB memory", poll.Name, d.Region, d.MemorySize))
+	return poll.Name, nil
+}
+
+//Stores the deployment next to its archive, so rollbacks can restore the source URL and configuration of every version
+func recordDeployment(d shared.Deployment, storageClient *storage.Client) error {
+	record, err := json.Marshal(d.WithoutSecrets())
+	if err != nil {
+		return fmt.Errorf("unable to serialize deployment of function %v, Error: %v", d.Name, err)
+	}
+
+	writer := storageClient.Bucket(shared.ArchiveBucketName).Object(historyKey(d.Name, d.Region, d.Version)).NewWriter(context.Background())
+	if _, err = writer.Write(record); err != nil {
+		writer.Close()
+		return fmt.Errorf("unable to write deployment history of function %v, Error: %v", d.Name, err)
+	}
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("unable to write deployment history of function %v, Error: %v", d.Name, err)
+	}
+	return nil
 }
 
-func getDeployedFunctions(functionsClient *functions.CloudFunctionsClient) []string {
+func historyPrefix(name string, region string) string {
+	return fmt.Sprintf("%v/history/%v/", name, region)
+}
+
+func historyKey(name string, region string, version string) string {
+	return fmt.Sprintf("%v%v.json", historyPrefix(name, region), version)
+}
+
+func getDeployedFunctions(functionsClient *functions.CloudFunctionsClient) ([]string, error) {
 	var f []string
 
 	listFunctions := functionsClient.ListFunctions(context.Background(), &functions2.ListFunctionsRequest{Parent: fmt.Sprintf("projects/%v/locations/-", viper.GetString(shared.GoogleProjectID))})
@@ -186,10 +332,12 @@ func getDeployedFunctions(functionsClient *functions.CloudFunctionsClient) []str
 		if err == iterator.Done {
 			break
 		}
-		shared.CheckErr(err, err)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list deployed functions, Error: %v", err)
+		}
 		f = append(f, item.Name)
 	}
-	return f
+	return f, nil
 }
 
 //Helper function
@@ -201,15 +349,16 @@ func isAWSStorageURL(url string) bool {
 	return strings.HasPrefix(url, "https://") && strings.Contains(url, "s3.amazonaws.com/")
 }
 
-func copyFromAWSToGoogle(srcURL string, storageClient *storage.Client) string {
+func copyFromAWSToGoogle(c shared.CredentialsHolder, srcURL string, targetKey string, metadata map[string]string, storageClient *storage.Client) error {
 	bucket, key := shared.ParseStorageObjectURI(srcURL)
 	if bucket == "" && key == "" {
-		fmt.Fprintln(os.Stderr, "Error:", fmt.Sprintf("unable to parse S3 object URI {%v}", srcURL))
-		os.Exit(1)
+		return fmt.Errorf("unable to parse S3 object URI {%v}", srcURL)
 	}
-	fmt.Printf("Bucket: %v, Key: %v\n", bucket, key)
 
-	cfg := aws.SetupConfig(shared.DefaultAWSRegion, credHolder)
+	cfg, err := aws.LoadConfig(shared.DefaultAWSRegion, c)
+	if err != nil {
+		return err
+	}
 	s3Client := s3.NewFromConfig(cfg)
 
 	getObjectInput := &s3.GetObjectInput{
@@ -218,32 +367,72 @@ func copyFromAWSToGoogle(srcURL string, storageClient *storage.Client) string {
 	}
 
 	object, err := s3Client.GetObject(context.Background(), getObjectInput)
-	shared.CheckErr(err, fmt.Sprintf("unable to get object from S3, Error: %v", err))
+	if err != nil {
+		return fmt.Errorf("unable to get object from S3, Error: %v", err)
+	}
 	defer object.Body.Close()
 
-	//Create bucket if it doesn't exist
-	bucketHandle := storageClient.Bucket(shared.ArchiveBucketName)
-	_, err = bucketHandle.Attrs(context.Background())
+	bucketHandle, err := getArchiveBucket(storageClient)
 	if err != nil {
-		if strings.Contains(err.Error(), "bucket doesn't exist") {
-			fmt.Println("Bucket doesn't exist, creating new one")
-			if err = bucketHandle.Create(context.Background(), viper.GetString(shared.GoogleProjectID), nil); err != nil {
-				shared.CheckErr(err, fmt.Sprintf("unable to create bucket on GCP, Error %v", err))
-			}
-		} else {
-			shared.CheckErr(err, fmt.Sprintf("unable to access bucket on GCP, Error %v", err))
-		}
+		return err
 	}
 
-	writer := bucketHandle.Object(key).NewWriter(context.Background())
+	writer := bucketHandle.Object(targetKey).NewWriter(context.Background())
+	writer.Metadata = metadata
 
 	if _, err = io.Copy(writer, object.Body); err != nil {
-		log.Fatalf("io.Copy: %v", err)
+		writer.Close()
+		return fmt.Errorf("io.Copy: %v", err)
 	}
 
 	if err = writer.Close(); err != nil {
-		log.Fatalf("Writer.Close: %v", err)
+		return fmt.Errorf("Writer.Close: %v", err)
 	}
+	return nil
+}
 
-	return key
+//Converts the tags of the deployment to labels and adds the version label, tags that do not follow the rules of Google labels are normalized
+func getLabels(d shared.Deployment) map[string]string {
+	labels, changes := toLabels(d.Tags)
+	for _, change := range changes {
+		shared.Log(shared.ProviderGoogle, fmt.Sprintf("Function %v in region %v: %v", d.Name, d.Region, change))
+	}
+	labels[shared.GoogleVersionLabel] = d.Version
+	return labels
+}
+
+//Short connector names are resolved within the project and region of the function
+func getVpcConnector(d shared.Deployment) string {
+	connector := d.Network.VpcConnector
+	if connector == "" || strings.HasPrefix(connector, "projects/") {
+		return connector
+	}
+	return fmt.Sprintf("projects/%v/locations/%v/connectors/%v", viper.GetString(shared.GoogleProjectID), d.Region, connector)
+}
+
+func getEgressSettings(d shared.Deployment) functions2.CloudFunction_VpcConnectorEgressSettings {
+	if d.Network.VpcConnector == "" {
+		return functions2.CloudFunction_VPC_CONNECTOR_EGRESS_SETTINGS_UNSPECIFIED
+	}
+	if d.Network.Egress == shared.EgressAll {
+		return functions2.CloudFunction_ALL_TRAFFIC
+	}
+	return functions2.CloudFunction_PRIVATE_RANGES_ONLY
+}
+
+func getIngressSettings(d shared.Deployment) functions2.CloudFunction_IngressSettings {
+	switch d.Network.Ingress {
+	case shared.IngressInternalOnly:
+		return functions2.CloudFunction_ALLOW_INTERNAL_ONLY
+	case shared.IngressInternalAndGclb:
+		return functions2.CloudFunction_ALLOW_INTERNAL_AND_GCLB
+	}
+	return functions2.CloudFunction_ALLOW_ALL
+}
+
+func getMaxInstances(d shared.Deployment) int32 {
+	if d.MaxInstances > 0 {
+		return d.MaxInstances
+	}
+	return shared.DefaultMaxFunctionInstances
 }
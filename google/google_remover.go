@@ -0,0 +1,114 @@
+package google
+
+import (
+	functions "cloud.google.com/go/functions/apiv1"
+	"cloud.google.com/go/storage"
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	functions2 "google.golang.org/genproto/googleapis/cloud/functions/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+//The archive bucket is shared by all regions, so the archives of every function are only deleted once
+var deleteArchiveLock sync.Mutex
+var deletedArchives = make(map[string]bool)
+
+func (Client) DeleteFunction(cfg shared.Config, de shared.Deployment, removeArchive bool) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(de)
+	result.Action = shared.ActionDelete
+	credentialsHolder := cfg.Credentials
+
+	functionsClient, err := functions.NewCloudFunctionsClient(context.Background(), option.WithCredentials(credentialsHolder.GoogleCredentials))
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to create Google cloud functions client, Error: %v", err), start)
+	}
+	defer functionsClient.Close()
+
+	if err = deleteFunction(credentialsHolder, de, functionsClient); err != nil {
+		return result.Fail(err, start)
+	}
+
+	if removeArchive {
+		storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(credentialsHolder.GoogleCredentials))
+		if err != nil {
+			return result.Fail(fmt.Errorf("unable to create Google storage client, Error: %v", err), start)
+		}
+		defer storageClient.Close()
+
+		if err = deleteArchive(de, storageClient); err != nil {
+			return result.Fail(err, start)
+		}
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func deleteFunction(c shared.CredentialsHolder, d shared.Deployment, functionsClient *functions.CloudFunctionsClient) error {
+	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Started deleting function %v in region %v", d.Name, d.Region))
+
+	start := time.Now()
+	functionName := fmt.Sprintf("projects/%v/locations/%v/functions/%v", viper.GetString(shared.GoogleProjectID), d.Region, d.Name)
+	deleteFunctionOperation, err := functionsClient.DeleteFunction(context.Background(), &functions2.DeleteFunctionRequest{Name: functionName})
+	if status.Code(err) == codes.NotFound {
+		shared.Log(shared.ProviderGoogle, fmt.Sprintf("Function %v in region %v does not exist, nothing to delete", d.Name, d.Region))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to delete function %v, Error: %v", functionName, err)
+	}
+
+	if err = deleteFunctionOperation.Wait(context.Background()); err != nil {
+		return fmt.Errorf("unable to wait for function deletion, Error: %v", err)
+	}
+	//Scheduler jobs are not deleted together with the function
+	if err = removeSchedule(c, d); err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Finished deleting function %v in region %v, took %s", d.Name, d.Region, elapsed))
+	return nil
+}
+
+//Deletes all archive versions and the deployment history of the function
+func deleteArchive(d shared.Deployment, storageClient *storage.Client) error {
+	deleteArchiveLock.Lock()
+	defer deleteArchiveLock.Unlock()
+	if deletedArchives[d.Name] {
+		return nil
+	}
+
+	bucketHandle := storageClient.Bucket(shared.ArchiveBucketName)
+	//Archives of local files were stored under the function name before they were versioned
+	objectKeys := []string{d.Name}
+	objects := bucketHandle.Objects(context.Background(), &storage.Query{Prefix: shared.ArchiveKey(d.Name, "")})
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done || err == storage.ErrBucketNotExist {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to list archives of function %v in bucket %v, Error: %v", d.Name, shared.ArchiveBucketName, err)
+		}
+		objectKeys = append(objectKeys, attrs.Name)
+	}
+
+	for _, objectKey := range objectKeys {
+		err := bucketHandle.Object(objectKey).Delete(context.Background())
+		if err != nil && err != storage.ErrObjectNotExist && err != storage.ErrBucketNotExist {
+			return fmt.Errorf("unable to delete archive %v from bucket %v on GCP, Error: %v", objectKey, shared.ArchiveBucketName, err)
+		}
+	}
+	deletedArchives[d.Name] = true
+
+	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Deleted %v archives of function %v from bucket %v", len(objectKeys)-1, d.Name, shared.ArchiveBucketName))
+	return nil
+}
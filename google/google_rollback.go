@@ -0,0 +1,111 @@
+package google
+
+import (
+	functions "cloud.google.com/go/functions/apiv1"
+	"cloud.google.com/go/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	functions2 "google.golang.org/genproto/googleapis/cloud/functions/v1"
+	"io"
+	"strings"
+	"time"
+)
+
+//Restores the source archive and configuration recorded for a previous version of the function
+func (Client) RollbackFunction(cfg shared.Config, de shared.Deployment, requestedVersion string) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(de)
+	result.Action = shared.ActionRollback
+	credentialsHolder := cfg.Credentials
+
+	storageClient, err := storage.NewClient(context.Background(), option.WithCredentials(credentialsHolder.GoogleCredentials))
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to create Google storage client, Error: %v", err), start)
+	}
+	defer storageClient.Close()
+
+	functionsClient, err := functions.NewCloudFunctionsClient(context.Background(), option.WithCredentials(credentialsHolder.GoogleCredentials))
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to create Google cloud functions client, Error: %v", err), start)
+	}
+	defer functionsClient.Close()
+
+	versions, err := getRecordedVersions(de, storageClient)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	functionName := fmt.Sprintf("projects/%v/locations/%v/functions/%v", viper.GetString(shared.GoogleProjectID), de.Region, de.Name)
+	cloudFunction, err := functionsClient.GetFunction(context.Background(), &functions2.GetFunctionRequest{Name: functionName})
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to get function %v, Error: %v", functionName, err), start)
+	}
+
+	version, err := shared.RollbackVersion(versions, cloudFunction.Labels[shared.GoogleVersionLabel], requestedVersion)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	result.Version = version
+	record, err := readDeploymentRecord(de, version, storageClient)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Started rolling back function %v in region %v to version %v", de.Name, de.Region, version))
+	if result.Function, err = updateFunction(credentialsHolder, record, functionsClient); err != nil {
+		return result.Fail(err, start)
+	}
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Finished rolling back function %v in region %v to version %v, took %s", de.Name, de.Region, version, result.Duration))
+	return result
+}
+
+//Returns the versions recorded by Deploy for the function in the region of the deployment
+func getRecordedVersions(d shared.Deployment, storageClient *storage.Client) ([]string, error) {
+	var versions []string
+	prefix := historyPrefix(d.Name, d.Region)
+
+	objects := storageClient.Bucket(shared.ArchiveBucketName).Objects(context.Background(), &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to list deployment history of function %v, Error: %v", d.Name, err)
+		}
+		versions = append(versions, strings.TrimSuffix(strings.TrimPrefix(attrs.Name, prefix), ".json"))
+	}
+	return versions, nil
+}
+
+func readDeploymentRecord(d shared.Deployment, version string, storageClient *storage.Client) (shared.Deployment, error) {
+	reader, err := storageClient.Bucket(shared.ArchiveBucketName).Object(historyKey(d.Name, d.Region, version)).NewReader(context.Background())
+	if err != nil {
+		return shared.Deployment{}, fmt.Errorf("unable to read deployment history of function %v, Error: %v", d.Name, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return shared.Deployment{}, fmt.Errorf("unable to read deployment history of function %v, Error: %v", d.Name, err)
+	}
+
+	var record shared.Deployment
+	if err = json.Unmarshal(content, &record); err != nil {
+		return shared.Deployment{}, fmt.Errorf("unable to parse deployment history of function %v, Error: %v", d.Name, err)
+	}
+
+	//Secrets are not recorded, so they are resolved again from the local environment
+	record, missing := record.ResolveSecrets()
+	if len(missing) > 0 {
+		return shared.Deployment{}, fmt.Errorf("unable to resolve secrets of version %v of function %v, local environment variables %v are not set", version, d.Name, strings.Join(missing, ", "))
+	}
+	return record, nil
+}
@@ -0,0 +1,200 @@
+package google
+
+import (
+	functions "cloud.google.com/go/functions/apiv1"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+	"google.golang.org/api/cloudscheduler/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/pubsub/v1"
+	functions2 "google.golang.org/genproto/googleapis/cloud/functions/v1"
+	iampb "google.golang.org/genproto/googleapis/iam/v1"
+	"net/http"
+)
+
+//Role and member that allow unauthenticated invocations of public http triggers
+const invokerRole = "roles/cloudfunctions.invoker"
+const allUsers = "allUsers"
+
+//Sets the trigger of the function, Google Cloud Functions support exactly one trigger and default to http.
+//Schedules publish to a topic of the function, which has to exist before the function is deployed
+func setTrigger(c shared.CredentialsHolder, function *functions2.CloudFunction, d shared.Deployment) error {
+	projectID := viper.GetString(shared.GoogleProjectID)
+	trigger := getTrigger(d)
+
+	var eventType, resource string
+	switch trigger.Type {
+	case shared.TriggerSchedule:
+		resource = scheduleTopic(d)
+		if err := createScheduleTopic(c, resource); err != nil {
+			return err
+		}
+		eventType = "google.pubsub.topic.publish"
+	case shared.TriggerQueue, shared.TriggerTopic:
+		resource = fmt.Sprintf("projects/%v/topics/%v", projectID, trigger.Resource())
+		eventType = "google.pubsub.topic.publish"
+	case shared.TriggerBucket:
+		resource = fmt.Sprintf("projects/_/buckets/%v", trigger.Bucket)
+		eventType = "google.storage.object.finalize"
+		if trigger.BucketEvents()[0] == shared.BucketEventDeleted {
+			eventType = "google.storage.object.delete"
+		}
+	default:
+		function.Trigger = &functions2.CloudFunction_HttpsTrigger{}
+		return nil
+	}
+	function.Trigger = &functions2.CloudFunction_EventTrigger{EventTrigger: &functions2.EventTrigger{EventType: eventType, Resource: resource}}
+	return nil
+}
+
+//Creates or deletes the schedule and updates the invoker permission after the function has been deployed
+func reconcileTriggers(c shared.CredentialsHolder, d shared.Deployment, function *functions2.CloudFunction, functionsClient *functions.CloudFunctionsClient) error {
+	trigger := getTrigger(d)
+	var err error
+	if trigger.Type == shared.TriggerSchedule {
+		err = putScheduleJob(c, d, trigger.Schedule)
+	} else {
+		err = removeSchedule(c, d)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = setPublic(function.Name, trigger.Type == shared.TriggerHTTP && trigger.Public, functionsClient); err != nil {
+		return err
+	}
+	if url := function.GetHttpsTrigger().GetUrl(); url != "" {
+		shared.Log(shared.ProviderGoogle, fmt.Sprintf("Function %v in region %v is available at %v", d.Name, d.Region, url))
+	}
+	return nil
+}
+
+func getTrigger(d shared.Deployment) shared.Trigger {
+	if len(d.Triggers) == 0 {
+		return shared.Trigger{Type: shared.TriggerHTTP}
+	}
+	return d.Triggers[0]
+}
+
+//Schedules are created per region, otherwise every deployed region would be invoked by the jobs of all regions
+func scheduleTopic(d shared.Deployment) string {
+	return fmt.Sprintf("projects/%v/topics/godeploy-schedule-%v-%v", viper.GetString(shared.GoogleProjectID), d.Name, d.Region)
+}
+
+func scheduleJob(d shared.Deployment) string {
+	return fmt.Sprintf("projects/%v/locations/%v/jobs/godeploy-%v", viper.GetString(shared.GoogleProjectID), d.Region, d.Name)
+}
+
+func createScheduleTopic(c shared.CredentialsHolder, topic string) error {
+	pubsubService, err := pubsub.NewService(context.Background(), option.WithCredentials(c.GoogleCredentials))
+	if err != nil {
+		return fmt.Errorf("unable to create Google pubsub client, Error: %v", err)
+	}
+
+	_, err = pubsubService.Projects.Topics.Create(topic, &pubsub.Topic{}).Do()
+	if err != nil && !hasStatus(err, http.StatusConflict) {
+		return fmt.Errorf("unable to create topic %v, Error: %v", topic, err)
+	}
+	return nil
+}
+
+//Creates the scheduler job that publishes to the topic of the function, or updates its schedule
+func putScheduleJob(c shared.CredentialsHolder, d shared.Deployment, schedule string) error {
+	schedulerService, err := cloudscheduler.NewService(context.Background(), option.WithCredentials(c.GoogleCredentials))
+	if err != nil {
+		return fmt.Errorf("unable to create Google cloud scheduler client, Error: %v", err)
+	}
+
+	job := &cloudscheduler.Job{
+		Name:        scheduleJob(d),
+		Description: fmt.Sprintf("Schedule of function %v", d.Name),
+		Schedule:    schedule,
+		TimeZone:    "UTC",
+		PubsubTarget: &cloudscheduler.PubsubTarget{
+			TopicName: scheduleTopic(d),
+			Data:      base64.StdEncoding.EncodeToString([]byte("{}")),
+		},
+	}
+	_, err = schedulerService.Projects.Locations.Jobs.Patch(job.Name, job).Do()
+	if hasStatus(err, http.StatusNotFound) {
+		_, err = schedulerService.Projects.Locations.Jobs.Create(fmt.Sprintf("projects/%v/locations/%v", viper.GetString(shared.GoogleProjectID), d.Region), job).Do()
+		shared.Log(shared.ProviderGoogle, fmt.Sprintf("Added schedule trigger %v to function %v in region %v", schedule, d.Name, d.Region))
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create scheduler job %v, Error: %v", job.Name, err)
+	}
+	return nil
+}
+
+//Deletes the scheduler job and the topic of a schedule, if the function has one
+func removeSchedule(c shared.CredentialsHolder, d shared.Deployment) error {
+	schedulerService, err := cloudscheduler.NewService(context.Background(), option.WithCredentials(c.GoogleCredentials))
+	if err != nil {
+		return fmt.Errorf("unable to create Google cloud scheduler client, Error: %v", err)
+	}
+
+	_, err = schedulerService.Projects.Locations.Jobs.Delete(scheduleJob(d)).Do()
+	if hasStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to delete scheduler job %v, Error: %v", scheduleJob(d), err)
+	}
+
+	pubsubService, err := pubsub.NewService(context.Background(), option.WithCredentials(c.GoogleCredentials))
+	if err != nil {
+		return fmt.Errorf("unable to create Google pubsub client, Error: %v", err)
+	}
+	_, err = pubsubService.Projects.Topics.Delete(scheduleTopic(d)).Do()
+	if err != nil && !hasStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("unable to delete topic %v, Error: %v", scheduleTopic(d), err)
+	}
+	shared.Log(shared.ProviderGoogle, fmt.Sprintf("Removed schedule trigger of function %v in region %v", d.Name, d.Region))
+	return nil
+}
+
+//Grants or revokes the invoker role of all users, the policy is only written if it changes
+func setPublic(functionName string, public bool, functionsClient *functions.CloudFunctionsClient) error {
+	policy, err := functionsClient.GetIamPolicy(context.Background(), &iampb.GetIamPolicyRequest{Resource: functionName})
+	if err != nil {
+		return fmt.Errorf("unable to get IAM policy of function %v, Error: %v", functionName, err)
+	}
+
+	var invoker *iampb.Binding
+	for _, binding := range policy.Bindings {
+		if binding.Role == invokerRole {
+			invoker = binding
+		}
+	}
+	if invoker == nil {
+		if !public {
+			return nil
+		}
+		invoker = &iampb.Binding{Role: invokerRole}
+		policy.Bindings = append(policy.Bindings, invoker)
+	}
+	if shared.Contains(invoker.Members, allUsers) == public {
+		return nil
+	}
+
+	if public {
+		invoker.Members = append(invoker.Members, allUsers)
+	} else {
+		invoker.Members = shared.Filter(invoker.Members, func(member string) bool { return member != allUsers })
+	}
+	_, err = functionsClient.SetIamPolicy(context.Background(), &iampb.SetIamPolicyRequest{Resource: functionName, Policy: policy})
+	if err != nil {
+		return fmt.Errorf("unable to set IAM policy of function %v, Error: %v", functionName, err)
+	}
+	return nil
+}
+
+func hasStatus(err error, code int) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
@@ -0,0 +1,117 @@
+package google
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//Maximum number of labels and length of their keys and values
+const (
+	maxLabels      = 64
+	maxLabelLength = 63
+)
+
+//Labels may only contain lowercase letters, digits, underscores and dashes, keys have to start with a letter
+var invalidLabelCharacters = regexp.MustCompile(`[^a-z0-9_-]`)
+
+var limits = shared.ProviderLimits{
+	MemorySizes: []int32{128, 256, 512, 1024, 2048, 4096, 8192},
+	MinTimeout:  1,
+	MaxTimeout:  540,
+	Runtimes: []string{
+		"nodejs10", "nodejs12", "nodejs14", "nodejs16",
+		"python37", "python38", "python39",
+		"go113", "go116", "java11", "dotnet3",
+		"ruby26", "ruby27", "php74",
+	},
+	Regions: []string{
+		"us-central1", "us-east1", "us-east4", "us-west1", "us-west2", "us-west3", "us-west4",
+		"northamerica-northeast1", "southamerica-east1",
+		"europe-central2", "europe-west1", "europe-west2", "europe-west3", "europe-west6",
+		"asia-east1", "asia-east2", "asia-northeast1", "asia-northeast2", "asia-northeast3",
+		"asia-south1", "asia-southeast1", "asia-southeast2", "australia-southeast1",
+	},
+	ReservedEnvironment: []string{
+		"X_GOOGLE_", "FUNCTION_TARGET", "FUNCTION_SIGNATURE_TYPE", "K_SERVICE", "K_REVISION", "PORT",
+	},
+	NetworkKeys:       []string{"vpcConnector", "egress", "ingress"},
+	MinInstancesLimit: "maxInstances",
+}
+
+func (Client) Validate(section string, key string, node *yaml.Node, report shared.ValidationReporter) {
+	switch key {
+	case "tags":
+		validateTags(node, report)
+	case "triggers":
+		validateTriggers(node, report)
+	}
+}
+
+func validateTags(tags *yaml.Node, report shared.ValidationReporter) {
+	//Tags that are normalized to the same label would overwrite each other
+	labels := make(map[string]string)
+	for i := 0; i+1 < len(tags.Content); i += 2 {
+		keyNode, valueNode := tags.Content[i], tags.Content[i+1]
+		key := keyNode.Value
+		if key == "" || valueNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		label := toLabel(key, true)
+		if label == shared.GoogleVersionLabel {
+			report(keyNode, "tag %v is applied as label %v, which is set by GoDeploy", key, label)
+		} else if other, exists := labels[label]; exists {
+			report(keyNode, "tags %v and %v are both applied as label %v on %v", other, key, label, shared.ProviderGoogle)
+		}
+		labels[label] = key
+	}
+	//The managed-by tag and the version label are added to every function
+	if count := len(tags.Content) / 2; count > maxLabels-2 {
+		report(tags, "%v supports at most %v labels", shared.ProviderGoogle, maxLabels-2)
+	}
+}
+
+//Cloud Functions can only have a single trigger, which listens to a single event of a bucket
+func validateTriggers(triggers *yaml.Node, report shared.ValidationReporter) {
+	if len(triggers.Content) > 1 {
+		report(triggers, "%v supports only one trigger per function", shared.ProviderGoogle)
+	}
+	for _, trigger := range triggers.Content {
+		if trigger.Kind != yaml.MappingNode {
+			continue
+		}
+		if events := shared.GetValue(trigger, "events"); events != nil && events.Kind == yaml.SequenceNode && len(events.Content) > 1 {
+			report(events, "%v supports only one event per bucket trigger", shared.ProviderGoogle)
+		}
+	}
+}
+
+//Converts a tag key or value to a label, by lowercasing it, replacing invalid characters with underscores and truncating it
+func toLabel(value string, key bool) string {
+	label := invalidLabelCharacters.ReplaceAllString(strings.ToLower(value), "_")
+	if key && (label == "" || label[0] < 'a' || label[0] > 'z') {
+		label = "t" + label
+	}
+	if len(label) > maxLabelLength {
+		label = label[:maxLabelLength]
+	}
+	return label
+}
+
+//Converts tags to labels and returns a message for every tag that had to be changed
+func toLabels(tags map[string]string) (map[string]string, []string) {
+	labels := make(map[string]string)
+	var changes []string
+	for key, value := range tags {
+		labelKey, labelValue := toLabel(key, true), toLabel(value, false)
+		if labelKey != key || labelValue != value {
+			changes = append(changes, fmt.Sprintf("tag %v: %v is applied as label %v: %v", key, value, labelKey, labelValue))
+		}
+		labels[labelKey] = labelValue
+	}
+	sort.Strings(changes)
+	return labels, changes
+}
@@ -1,6 +1,14 @@
 package main
 
-import "godeploy/cmd"
+import (
+	"godeploy/cmd"
+	//Providers register themselves when their package is imported
+	_ "godeploy/aws"
+	_ "godeploy/azure"
+	_ "godeploy/google"
+	_ "godeploy/openfaas"
+	_ "godeploy/openwhisk"
+)
 
 func main() {
 	cmd.Execute()
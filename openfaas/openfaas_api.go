@@ -0,0 +1,182 @@
+package openfaas
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//Client for the REST API of the gateway of a region, authenticated with basic authentication
+type apiClient struct {
+	credentials credentials
+	gateway     string
+	http        *http.Client
+}
+
+//Error response of the gateway
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("status %v: %v", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+//Function deployment of the gateway API, used to create and update functions
+type functionDeployment struct {
+	Service     string            `json:"service"`
+	Image       string            `json:"image"`
+	Namespace   string            `json:"namespace,omitempty"`
+	EnvVars     map[string]string `json:"envVars,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Limits      *resources        `json:"limits,omitempty"`
+	Requests    *resources        `json:"requests,omitempty"`
+}
+
+//Kubernetes resources of the function, e.g. 256Mi memory
+type resources struct {
+	Memory string `json:"memory,omitempty"`
+}
+
+//Function as it is reported by the gateway
+type functionStatus struct {
+	Name              string            `json:"name"`
+	Image             string            `json:"image"`
+	Namespace         string            `json:"namespace"`
+	EnvVars           map[string]string `json:"envVars"`
+	Labels            map[string]string `json:"labels"`
+	Annotations       map[string]string `json:"annotations"`
+	Limits            *resources        `json:"limits"`
+	Replicas          uint64            `json:"replicas"`
+	AvailableReplicas uint64            `json:"availableReplicas"`
+}
+
+//Log line of a function, the gateway streams them as one JSON object per line
+type logMessage struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Instance  string `json:"instance"`
+	Timestamp string `json:"timestamp"`
+	Text      string `json:"text"`
+}
+
+//Returns a client for the gateway of the region, regions without a gateway in the credentials file are unknown
+func newAPIClient(cfg shared.Config, region string) (*apiClient, error) {
+	c, ok := cfg.Credentials.Providers[shared.ProviderOpenFaaS].(credentials)
+	if !ok {
+		return nil, fmt.Errorf("credentials of %v are not loaded", shared.ProviderOpenFaaS)
+	}
+	gateway, ok := c.Gateways[region]
+	if !ok {
+		return nil, fmt.Errorf("unknown region %v of %v, add the gateway of its cluster to %v in the credentials file", region, shared.ProviderOpenFaaS, shared.OpenFaaSGatewaysKey)
+	}
+	if !strings.Contains(gateway, "://") {
+		gateway = "https://" + gateway
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: c.Insecure}
+	return &apiClient{credentials: c, gateway: strings.TrimSuffix(gateway, "/"), http: &http.Client{Transport: transport}}, nil
+}
+
+//Returns the regions of all gateways in the credentials file in alphabetical order
+func getRegions(cfg shared.Config) []string {
+	c, _ := cfg.Credentials.Providers[shared.ProviderOpenFaaS].(credentials)
+	var regions []string
+	for region := range c.Gateways {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+//Returns the URL of a path of the gateway, the namespace of the credentials is added to the query if it is set
+func (c *apiClient) systemURL(path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.credentials.Namespace != "" {
+		query.Set("namespace", c.credentials.Namespace)
+	}
+	requestURL := c.gateway + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	return requestURL
+}
+
+//Returns the URL the gateway invokes the function at, functions outside the default namespace are addressed as <NAME>.<NAMESPACE>
+func (c *apiClient) functionURL(name string) string {
+	if c.credentials.Namespace != "" {
+		name += "." + c.credentials.Namespace
+	}
+	return fmt.Sprintf("%v/function/%v", c.gateway, url.PathEscape(name))
+}
+
+//Sends the request and returns the response regardless of its status, JSON request bodies are encoded from in
+func (c *apiClient) do(method string, requestURL string, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	switch value := in.(type) {
+	case nil:
+	case []byte:
+		body = bytes.NewReader(value)
+	default:
+		content, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize request to %v, Error: %v", requestURL, err)
+		}
+		body = bytes.NewReader(content)
+	}
+
+	request, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request to %v, Error: %v", requestURL, err)
+	}
+	request.SetBasicAuth(c.credentials.User, c.credentials.Password)
+	request.Header.Set("Content-Type", "application/json")
+	return c.http.Do(request)
+}
+
+//Sends the request and decodes the response into out, responses with an error status are returned as apiError
+func (c *apiClient) request(method string, requestURL string, in interface{}, out interface{}) error {
+	response, err := c.do(method, requestURL, in)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response of %v, Error: %v", requestURL, err)
+	}
+	if response.StatusCode >= 400 {
+		return &apiError{StatusCode: response.StatusCode, Message: strings.TrimSpace(string(content))}
+	}
+	if out == nil || len(content) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("unable to parse response of %v, Error: %v", requestURL, err)
+	}
+	return nil
+}
+
+func (c *apiClient) getFunction(name string) (functionStatus, error) {
+	var f functionStatus
+	err := c.request(http.MethodGet, c.systemURL("/system/function/"+url.PathEscape(name), nil), nil, &f)
+	return f, err
+}
@@ -0,0 +1,167 @@
+package openfaas
+
+import (
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"godeploy/shared/providertest"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testNamespace = "fn"
+
+//In memory stand-in for the gateway of an OpenFaaS cluster
+type fakeGateway struct {
+	functions map[string]functionStatus
+	//Methods of the requests deploying functions, in the order they were received
+	deployments []string
+	//Log lines returned for every function, one JSON object per line like the gateway streams them
+	logs []logMessage
+}
+
+//Starts a fake gateway, the returned config holds credentials for it in the region cluster
+func newFakeGateway(t *testing.T) (*fakeGateway, shared.Config) {
+	fake := &fakeGateway{functions: map[string]functionStatus{}}
+	server := providertest.NewServer(t, http.HandlerFunc(fake.serve))
+	return fake, newTestConfig(server.URL)
+}
+
+func newTestConfig(gateway string) shared.Config {
+	return shared.Config{
+		Region: "cluster",
+		Credentials: shared.CredentialsHolder{Providers: map[shared.ProviderName]interface{}{
+			shared.ProviderOpenFaaS: credentials{User: defaultUser, Password: "password", Namespace: testNamespace, Gateways: map[string]string{"cluster": gateway}},
+		}},
+	}
+}
+
+//Returns a deployment of the test function with an image tagged with the version
+func newTestDeployment(t *testing.T, version string, environment map[string]string) shared.Deployment {
+	d := providertest.NewDeployment(t, shared.ProviderOpenFaaS, "cluster", version, environment)
+	d.Runtime = "ghcr.io/acme/hello:" + version
+	return d
+}
+
+func (f *fakeGateway) serve(w http.ResponseWriter, r *http.Request) {
+	if user, password, _ := r.BasicAuth(); user != defaultUser || password != "password" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/system/") && r.URL.Query().Get("namespace") != testNamespace {
+		http.Error(w, "namespace is missing", http.StatusBadRequest)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.URL.Path == "/system/functions" && r.Method == http.MethodGet:
+		var names []string
+		for name := range f.functions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		functions := []functionStatus{}
+		for _, name := range names {
+			functions = append(functions, f.functions[name])
+		}
+		providertest.WriteJSON(w, http.StatusOK, functions)
+	case r.URL.Path == "/system/functions" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		f.deploy(w, r.Method, body)
+	case r.URL.Path == "/system/functions" && r.Method == http.MethodDelete:
+		var request struct {
+			FunctionName string `json:"functionName"`
+		}
+		json.Unmarshal(body, &request)
+		if _, ok := f.functions[request.FunctionName]; !ok {
+			http.Error(w, fmt.Sprintf("function %v not found", request.FunctionName), http.StatusNotFound)
+			return
+		}
+		delete(f.functions, request.FunctionName)
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(r.URL.Path, "/system/function/"):
+		function, ok := f.functions[strings.TrimPrefix(r.URL.Path, "/system/function/")]
+		if !ok {
+			http.Error(w, "function not found", http.StatusNotFound)
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, function)
+	case r.URL.Path == "/system/logs":
+		for _, message := range f.logs {
+			if message.Name == r.URL.Query().Get("name") {
+				json.NewEncoder(w).Encode(message)
+			}
+		}
+	case strings.HasPrefix(r.URL.Path, "/function/"):
+		//Functions are addressed as <NAME>.<NAMESPACE> outside the default namespace
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/function/"), "."+testNamespace)
+		if _, ok := f.functions[name]; !ok {
+			http.Error(w, "function not found", http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "hello %s", body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+//Creating an existing function and updating a missing one fail like on the gateway
+func (f *fakeGateway) deploy(w http.ResponseWriter, method string, body []byte) {
+	var request functionDeployment
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, exists := f.functions[request.Service]
+	if method == http.MethodPost && exists {
+		http.Error(w, fmt.Sprintf("function %v already exists", request.Service), http.StatusConflict)
+		return
+	} else if method == http.MethodPut && !exists {
+		http.Error(w, fmt.Sprintf("function %v not found", request.Service), http.StatusNotFound)
+		return
+	}
+	f.deployments = append(f.deployments, method)
+	f.functions[request.Service] = functionStatus{
+		Name:              request.Service,
+		Image:             request.Image,
+		Namespace:         request.Namespace,
+		EnvVars:           request.EnvVars,
+		Labels:            request.Labels,
+		Annotations:       request.Annotations,
+		Limits:            request.Limits,
+		Replicas:          1,
+		AvailableReplicas: 1,
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func TestFunctionURL(t *testing.T) {
+	client := &apiClient{credentials: credentials{Namespace: testNamespace}, gateway: "https://gateway.test"}
+	if got, want := client.functionURL("hello"), "https://gateway.test/function/hello.fn"; got != want {
+		t.Errorf("functionURL in namespace = %v, want %v", got, want)
+	}
+
+	client.credentials.Namespace = ""
+	if got, want := client.functionURL("hello"), "https://gateway.test/function/hello"; got != want {
+		t.Errorf("functionURL in default namespace = %v, want %v", got, want)
+	}
+	if got, want := client.systemURL("/system/functions", nil), "https://gateway.test/system/functions"; got != want {
+		t.Errorf("systemURL in default namespace = %v, want %v", got, want)
+	}
+}
+
+func TestNewAPIClientUnknownRegion(t *testing.T) {
+	cfg := newTestConfig("gateway.test")
+	if _, err := newAPIClient(cfg, "unknown"); err == nil {
+		t.Error("newAPIClient of unknown region succeeded")
+	}
+
+	//Gateways without scheme use https
+	client, err := newAPIClient(cfg, "cluster")
+	if err != nil || client.gateway != "https://gateway.test" {
+		t.Errorf("newAPIClient = %+v, %v, want gateway https://gateway.test", client, err)
+	}
+}
@@ -0,0 +1,202 @@
+package openfaas
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//Implementation of shared.Client for OpenFaaS, functions are deployed through the gateway of every region
+type Client struct{}
+
+//Functions are created or updated depending on whether they exist, which is checked when deploying
+func (Client) CreateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d)
+}
+
+func (Client) UpdateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d)
+}
+
+//Lists the functions of the namespace in the given region, or in all regions of the credentials file if no region is given
+func (Client) ListFunctions(cfg shared.Config) ([]shared.Function, error) {
+	regions := []string{cfg.Region}
+	if cfg.Region == "" {
+		regions = getRegions(cfg)
+	}
+
+	var f []shared.Function
+	for _, region := range regions {
+		client, err := newAPIClient(cfg, region)
+		if err != nil {
+			return nil, err
+		}
+
+		var functions []functionStatus
+		err = client.request(http.MethodGet, client.systemURL("/system/functions", nil), nil, &functions)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list functions in region %v, Error: %v", region, err)
+		}
+		for _, function := range functions {
+			f = append(f, mapFunction(function, region))
+		}
+	}
+	return f, nil
+}
+
+func (Client) GetFunction(cfg shared.Config, name string) (*shared.Function, error) {
+	client, err := newAPIClient(cfg, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	function, err := client.getFunction(name)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get function %v in region %v, Error: %v", name, cfg.Region, err)
+	}
+
+	f := mapFunction(function, cfg.Region)
+	return &f, nil
+}
+
+//The handler is defined by the template the image was built from, so it is not compared
+func (Client) DesiredFunction(d shared.Deployment) shared.Function {
+	maxInstances := d.MaxInstances
+	if maxInstances == 0 {
+		maxInstances = shared.DefaultMaxFunctionInstances
+	}
+	return shared.Function{
+		Name:         d.Name,
+		Provider:     shared.ProviderOpenFaaS,
+		Region:       d.Region,
+		Runtime:      d.Runtime,
+		MemorySize:   d.MemorySize,
+		Timeout:      d.Timeout,
+		Environment:  d.Environment,
+		MaxInstances: maxInstances,
+		MinInstances: d.MinInstances,
+	}
+}
+
+//Invokes the function synchronously through the gateway, the payload is sent as request body
+func (Client) InvokeFunction(cfg shared.Config, name string, payload []byte) shared.Invocation {
+	client, err := newAPIClient(cfg, cfg.Region)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: err.Error()}
+	}
+
+	response, err := client.do(http.MethodPost, client.functionURL(name), payload)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to invoke function %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return shared.Invocation{Status: fmt.Sprint(response.StatusCode), Error: fmt.Sprintf("unable to read response of function %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+
+	invocation := shared.Invocation{
+		Status: fmt.Sprint(response.StatusCode),
+		Body:   string(body),
+	}
+	if response.StatusCode >= 400 {
+		invocation.Error = string(body)
+	}
+	return invocation
+}
+
+//Returns the log lines of all replicas of the function since the given time
+func (Client) GetLogs(cfg shared.Config, name string, since time.Time) ([]shared.LogEntry, error) {
+	client, err := newAPIClient(cfg, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"name":   {name},
+		"since":  {since.UTC().Format(time.RFC3339)},
+		"follow": {strconv.FormatBool(false)},
+	}
+	response, err := client.do(http.MethodGet, client.systemURL("/system/logs", query), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get logs of function %v in region %v, Error: %v", name, cfg.Region, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 400 {
+		body, _ := io.ReadAll(response.Body)
+		err = &apiError{StatusCode: response.StatusCode, Message: string(body)}
+		return nil, fmt.Errorf("unable to get logs of function %v in region %v, Error: %v", name, cfg.Region, err)
+	}
+
+	var entries []shared.LogEntry
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		var message logMessage
+		if json.Unmarshal(scanner.Bytes(), &message) != nil {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, message.Timestamp)
+		if err != nil || timestamp.Before(since) {
+			continue
+		}
+		entries = append(entries, shared.LogEntry{Timestamp: timestamp, Provider: shared.ProviderOpenFaaS, Region: cfg.Region, Message: message.Text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read logs of function %v in region %v, Error: %v", name, cfg.Region, err)
+	}
+
+	//Lines of different replicas are interleaved
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, nil
+}
+
+func mapFunction(function functionStatus, region string) shared.Function {
+	f := shared.Function{
+		Name:       function.Name,
+		Provider:   shared.ProviderOpenFaaS,
+		Region:     region,
+		Runtime:    function.Image,
+		MemorySize: parseMemory(function.Limits),
+		Timeout:    parseTimeout(function.EnvVars),
+		State:      getState(function),
+	}
+	for name, value := range function.EnvVars {
+		if shared.Contains(timeoutVariables, name) {
+			continue
+		}
+		if f.Environment == nil {
+			f.Environment = make(map[string]string)
+		}
+		f.Environment[name] = value
+	}
+	if value, err := strconv.Atoi(function.Labels[scaleMaxLabel]); err == nil {
+		f.MaxInstances = int32(value)
+	}
+	if value, err := strconv.Atoi(function.Labels[scaleMinLabel]); err == nil {
+		f.MinInstances = int32(value)
+	}
+	return f
+}
+
+//Functions scaled to zero have no replicas, which is not reported as unavailable
+func getState(function functionStatus) string {
+	switch {
+	case function.Replicas == 0:
+		return "ScaledToZero"
+	case function.AvailableReplicas == 0:
+		return "Unavailable"
+	default:
+		return "Ready"
+	}
+}
@@ -0,0 +1,144 @@
+package openfaas
+
+import (
+	"godeploy/shared"
+	"godeploy/shared/providertest"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	fake, cfg := newFakeGateway(t)
+
+	providertest.Lifecycle{
+		Client: Client{},
+		Config: cfg,
+		Deployment: func(version string) shared.Deployment {
+			d := newTestDeployment(t, version, map[string]string{"VERSION": version})
+			d.MaxInstances = 10
+			d.MinInstances = 1
+			return d
+		},
+		AfterDeploy: func(t *testing.T, d shared.Deployment, result shared.DeploymentResult) {
+			//The first deployment creates the function with POST, the second one updates it with PUT
+			want := []string{http.MethodPost, http.MethodPut}[:len(fake.deployments)]
+			if len(fake.deployments) > 2 || fake.deployments[len(fake.deployments)-1] != want[len(want)-1] {
+				t.Errorf("deployment requests = %v, want %v", fake.deployments, want)
+			}
+			function := fake.functions[d.Name]
+			if function.Image != d.Runtime || function.Namespace != testNamespace {
+				t.Errorf("function = %v in %v, want image %v in %v", function.Image, function.Namespace, d.Runtime, testNamespace)
+			}
+			if function.Annotations[shared.OpenFaaSVersionAnnotation] != d.Version {
+				t.Errorf("annotations = %v, want version %v", function.Annotations, d.Version)
+			}
+			if result.Function != cfg.Credentials.Providers[shared.ProviderOpenFaaS].(credentials).Gateways["cluster"]+"/function/hello.fn" {
+				t.Errorf("function = %v, want the URL the gateway invokes it at", result.Function)
+			}
+		},
+	}.Run(t)
+}
+
+func TestListFunctions(t *testing.T) {
+	fake, cfg := newFakeGateway(t)
+	fake.functions["hello"] = functionStatus{Name: "hello", Image: "hello:1", Replicas: 0}
+	fake.functions["world"] = functionStatus{Name: "world", Image: "world:1", Replicas: 2, AvailableReplicas: 2}
+
+	//Without a region, the functions of all gateways are listed
+	functions, err := Client{}.ListFunctions(shared.Config{Credentials: cfg.Credentials})
+	if err != nil {
+		t.Fatalf("ListFunctions failed: %v", err)
+	}
+	if len(functions) != 2 {
+		t.Fatalf("ListFunctions returned %v functions, want 2", len(functions))
+	}
+	if functions[0].State != "ScaledToZero" || functions[1].State != "Ready" || functions[1].Region != "cluster" {
+		t.Errorf("functions = %+v, want hello scaled to zero and world ready in cluster", functions)
+	}
+}
+
+func TestInvokeFunction(t *testing.T) {
+	fake, cfg := newFakeGateway(t)
+	fake.functions["hello"] = functionStatus{Name: "hello"}
+
+	got := Client{}.InvokeFunction(cfg, "hello", []byte("world"))
+	if want := (shared.Invocation{Status: "200", Body: "hello world"}); got != want {
+		t.Errorf("InvokeFunction = %+v, want %+v", got, want)
+	}
+
+	missing := Client{}.InvokeFunction(cfg, "missing", nil)
+	if missing.Status != "404" || missing.Error == "" {
+		t.Errorf("InvokeFunction of missing function = %+v, want status 404 with an error", missing)
+	}
+
+	cfg.Region = "unknown"
+	if failed := (Client{}).InvokeFunction(cfg, "hello", nil); failed.Status != "FAILED" || failed.Error == "" {
+		t.Errorf("InvokeFunction in unknown region = %+v, want a failed invocation", failed)
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	fake, cfg := newFakeGateway(t)
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	//Lines of different replicas are interleaved and can be older than requested
+	fake.logs = []logMessage{
+		{Name: "hello", Instance: "a", Timestamp: "2024-05-01T12:00:02Z", Text: "second"},
+		{Name: "hello", Instance: "b", Timestamp: "2024-05-01T12:00:01Z", Text: "first"},
+		{Name: "hello", Instance: "a", Timestamp: "2024-05-01T11:59:59Z", Text: "too old"},
+		{Name: "hello", Instance: "a", Timestamp: "invalid", Text: "invalid"},
+		{Name: "world", Instance: "c", Timestamp: "2024-05-01T12:00:03Z", Text: "other function"},
+	}
+
+	entries, err := Client{}.GetLogs(cfg, "hello", since)
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Message != "first" || entries[1].Message != "second" {
+		t.Fatalf("GetLogs = %+v, want first and second", entries)
+	}
+	if entries[0].Region != "cluster" || entries[0].Provider != shared.ProviderOpenFaaS {
+		t.Errorf("entry = %+v, want %v in cluster", entries[0], shared.ProviderOpenFaaS)
+	}
+}
+
+//Failures are returned, so the logs of the other targets are still printed
+func TestGetLogsReturnsErrors(t *testing.T) {
+	_, cfg := newFakeGateway(t)
+	c := cfg.Credentials.Providers[shared.ProviderOpenFaaS].(credentials)
+	c.Password = "wrong"
+	cfg.Credentials.Providers[shared.ProviderOpenFaaS] = c
+
+	if entries, err := (Client{}).GetLogs(cfg, "hello", time.Now()); entries != nil || err == nil {
+		t.Errorf("GetLogs with wrong password = %+v, %v, want an error", entries, err)
+	}
+}
+
+func TestMapFunction(t *testing.T) {
+	function := functionStatus{
+		Name:    "hello",
+		Image:   "hello:1",
+		EnvVars: map[string]string{"LOG_LEVEL": "info", "read_timeout": "30s", "write_timeout": "30s", "exec_timeout": "30s"},
+		Labels:  map[string]string{scaleMinLabel: "1", scaleMaxLabel: "5"},
+		Limits:  &resources{Memory: "1Gi"},
+	}
+
+	f := mapFunction(function, "cluster")
+	//The timeouts of the watchdog are not environment variables of the function
+	want := shared.Function{
+		Name:         "hello",
+		Provider:     shared.ProviderOpenFaaS,
+		Region:       "cluster",
+		Runtime:      "hello:1",
+		MemorySize:   1024,
+		Timeout:      30,
+		State:        "ScaledToZero",
+		Environment:  map[string]string{"LOG_LEVEL": "info"},
+		MaxInstances: 5,
+		MinInstances: 1,
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("mapFunction = %+v, want %+v", f, want)
+	}
+}
@@ -0,0 +1,133 @@
+package openfaas
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Labels read by the autoscaler of OpenFaaS
+const (
+	scaleMinLabel = "com.openfaas.scale.min"
+	scaleMaxLabel = "com.openfaas.scale.max"
+)
+
+//Timeouts of the watchdog in the image, which are all set to the timeout of the function
+var timeoutVariables = []string{"read_timeout", "write_timeout", "exec_timeout"}
+
+//OpenFaaS pulls the image given as runtime from its registry, so there is no archive to upload
+func (Client) UploadArchive(cfg shared.Config, d shared.Deployment) (shared.Deployment, error) {
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	return d, nil
+}
+
+//Creates the function or updates it if it already exists, the gateway rolls it out after accepting the request
+func deploy(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	start := time.Now()
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	result := shared.NewDeploymentResult(d)
+
+	client, err := newAPIClient(cfg, d.Region)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	method := http.MethodPut
+	result.Action = shared.ActionUpdate
+	if _, err = client.getFunction(d.Name); isNotFound(err) {
+		method = http.MethodPost
+		result.Action = shared.ActionCreate
+	} else if err != nil {
+		return result.Fail(fmt.Errorf("unable to get function %v, Error: %v", d.Name, err), start)
+	}
+	shared.Log(shared.ProviderOpenFaaS, fmt.Sprintf("Started deploying function %v in region %v with image %v and %v MB memory", d.Name, d.Region, d.Runtime, d.MemorySize))
+
+	memory := &resources{Memory: fmt.Sprintf("%vMi", d.MemorySize)}
+	f := functionDeployment{
+		Service:     d.Name,
+		Image:       d.Runtime,
+		Namespace:   client.credentials.Namespace,
+		EnvVars:     getEnvVars(d),
+		Labels:      getLabels(d),
+		Annotations: getAnnotations(d),
+		Limits:      memory,
+		Requests:    memory,
+	}
+	if err = client.request(method, client.systemURL("/system/functions", nil), f, nil); err != nil {
+		return result.Fail(fmt.Errorf("unable to deploy function %v, Error: %v", d.Name, err), start)
+	}
+	//Functions are identified by the URL the gateway invokes them at
+	result.Function = client.functionURL(d.Name)
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderOpenFaaS, fmt.Sprintf("Finished deploying function %v in region %v as version %v, took %s", d.Name, d.Region, d.Version, result.Duration))
+	return result
+}
+
+//Environment variables of the function together with the timeouts of the watchdog
+func getEnvVars(d shared.Deployment) map[string]string {
+	envVars := make(map[string]string)
+	for name, value := range d.Environment {
+		envVars[name] = value
+	}
+	for _, name := range timeoutVariables {
+		envVars[name] = fmt.Sprintf("%vs", d.Timeout)
+	}
+	return envVars
+}
+
+//The maximum instances are always set, the minimum only if instances are kept warm, so the default of the autoscaler is used otherwise
+func getLabels(d shared.Deployment) map[string]string {
+	maxInstances := d.MaxInstances
+	if maxInstances == 0 {
+		maxInstances = shared.DefaultMaxFunctionInstances
+	}
+	labels := map[string]string{scaleMaxLabel: strconv.Itoa(int(maxInstances))}
+	if d.MinInstances > 0 {
+		labels[scaleMinLabel] = strconv.Itoa(int(d.MinInstances))
+	}
+	return labels
+}
+
+//Tags are applied as annotations of the function together with the version
+func getAnnotations(d shared.Deployment) map[string]string {
+	annotations := map[string]string{shared.OpenFaaSVersionAnnotation: d.Version}
+	for key, value := range d.Tags {
+		annotations[key] = value
+	}
+	return annotations
+}
+
+//Parses the timeout of the watchdog, which is a duration like 30s, 0 if it is not set
+func parseTimeout(envVars map[string]string) int32 {
+	timeout, err := time.ParseDuration(envVars["exec_timeout"])
+	if err != nil {
+		return 0
+	}
+	return int32(timeout.Seconds())
+}
+
+//Parses a Kubernetes memory quantity like 256Mi or 1Gi into MB, 0 if it is not set or uses another unit
+func parseMemory(r *resources) int32 {
+	if r == nil {
+		return 0
+	}
+	units := map[string]int64{"Mi": 1, "Gi": 1024, "M": 1, "G": 1000}
+	for _, unit := range []string{"Mi", "Gi", "M", "G"} {
+		if strings.HasSuffix(r.Memory, unit) {
+			size, err := strconv.ParseInt(strings.TrimSuffix(r.Memory, unit), 10, 32)
+			if err != nil {
+				return 0
+			}
+			return int32(size * units[unit])
+		}
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package openfaas
+
+import (
+	"godeploy/shared"
+	"reflect"
+	"testing"
+)
+
+func TestGetEnvVars(t *testing.T) {
+	d := shared.Deployment{Timeout: 45, Environment: map[string]string{"LOG_LEVEL": "info"}}
+
+	want := map[string]string{"LOG_LEVEL": "info", "read_timeout": "45s", "write_timeout": "45s", "exec_timeout": "45s"}
+	if got := getEnvVars(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("getEnvVars = %v, want %v", got, want)
+	}
+	if got := parseTimeout(getEnvVars(d)); got != 45 {
+		t.Errorf("parseTimeout(getEnvVars) = %v, want 45", got)
+	}
+}
+
+func TestGetLabels(t *testing.T) {
+	tests := []struct {
+		maxInstances int32
+		minInstances int32
+		want         map[string]string
+	}{
+		//The minimum is only set if instances are kept warm, so the autoscaler can scale to zero otherwise
+		{0, 0, map[string]string{scaleMaxLabel: "5"}},
+		{10, 0, map[string]string{scaleMaxLabel: "10"}},
+		{10, 2, map[string]string{scaleMaxLabel: "10", scaleMinLabel: "2"}},
+	}
+	for _, test := range tests {
+		d := shared.Deployment{MaxInstances: test.maxInstances, MinInstances: test.minInstances}
+		if got := getLabels(d); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("getLabels(%v, %v) = %v, want %v", test.maxInstances, test.minInstances, got, test.want)
+		}
+	}
+}
+
+func TestGetAnnotations(t *testing.T) {
+	d := shared.Deployment{Version: "v1", Tags: map[string]string{"team": "x"}}
+
+	want := map[string]string{"team": "x", shared.OpenFaaSVersionAnnotation: "v1"}
+	if got := getAnnotations(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("getAnnotations = %v, want %v", got, want)
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		memory string
+		want   int32
+	}{
+		{"256Mi", 256},
+		{"1Gi", 1024},
+		{"512M", 512},
+		{"2G", 2000},
+		{"1024Ki", 0},
+		{"largeMi", 0},
+		{"", 0},
+	}
+	for _, test := range tests {
+		if got := parseMemory(&resources{Memory: test.memory}); got != test.want {
+			t.Errorf("parseMemory(%q) = %v, want %v", test.memory, got, test.want)
+		}
+	}
+	if got := parseMemory(nil); got != 0 {
+		t.Errorf("parseMemory(nil) = %v, want 0", got)
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		want    int32
+	}{
+		{"30s", 30},
+		{"2m", 120},
+		{"1m30s", 90},
+		{"", 0},
+		{"30", 0},
+	}
+	for _, test := range tests {
+		if got := parseTimeout(map[string]string{"exec_timeout": test.timeout}); got != test.want {
+			t.Errorf("parseTimeout(%q) = %v, want %v", test.timeout, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package openfaas
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+)
+
+//User of the basic authentication of the gateway in the default installation
+const defaultUser = "admin"
+
+//Basic authentication and the gateways of all clusters, read from openfaas-credentials.yaml
+type credentials struct {
+	User     string
+	Password string
+	//Namespace functions are deployed to, empty uses the default namespace of the gateway
+	Namespace string
+	//Gateways by region, every region is a separate cluster
+	Gateways map[string]string
+	//Skips the verification of TLS certificates, e.g. for clusters with self-signed certificates
+	Insecure bool
+}
+
+func init() {
+	shared.RegisterProvider(Client{})
+}
+
+func (Client) Name() shared.ProviderName {
+	return shared.ProviderOpenFaaS
+}
+
+func (Client) Limits() shared.ProviderLimits {
+	return limits
+}
+
+func (Client) DefaultRegion() string {
+	return shared.DefaultOpenFaaSRegion
+}
+
+//OpenFaaS runs container images, so the runtime is the image of the function
+func (Client) DefaultRuntime() string {
+	return "<IMAGE>"
+}
+
+func (Client) CredentialsFile() string {
+	return shared.OpenFaaSCredentialsFile
+}
+
+func (Client) CredentialsTemplate() string {
+	return shared.OpenFaaSUserKey + `: "` + defaultUser + `"
+` + shared.OpenFaaSPasswordKey + `: "<PASSWORD>"
+` + shared.OpenFaaSNamespaceKey + `: ""
+` + shared.OpenFaaSInsecureKey + `: false
+` + shared.OpenFaaSGatewaysKey + `:
+  ` + shared.DefaultOpenFaaSRegion + `: "https://<GATEWAY>"
+`
+}
+
+//The keys of the credentials file are read from the configuration, the gateway of a region is looked up when it is used
+func (Client) LoadCredentials(file string, credentialsHolder *shared.CredentialsHolder) error {
+	c := credentials{
+		User:      viper.GetString(shared.OpenFaaSUserKey),
+		Password:  viper.GetString(shared.OpenFaaSPasswordKey),
+		Namespace: viper.GetString(shared.OpenFaaSNamespaceKey),
+		Gateways:  viper.GetStringMapString(shared.OpenFaaSGatewaysKey),
+		Insecure:  viper.GetBool(shared.OpenFaaSInsecureKey),
+	}
+	if c.Password == "" {
+		return fmt.Errorf("missing key %v in credentials file {%v}", shared.OpenFaaSPasswordKey, file)
+	}
+	if len(c.Gateways) == 0 {
+		return fmt.Errorf("missing key %v in credentials file {%v}, it maps every region to the gateway of its cluster", shared.OpenFaaSGatewaysKey, file)
+	}
+	if c.User == "" {
+		c.User = defaultUser
+	}
+
+	if credentialsHolder.Providers == nil {
+		credentialsHolder.Providers = make(map[shared.ProviderName]interface{})
+	}
+	credentialsHolder.Providers[shared.ProviderOpenFaaS] = c
+	return nil
+}
+
+//OpenFaaS pulls the image of the function from its registry, so archives are never stored at the provider
+func (Client) IsArchiveURI(archive string) bool {
+	return false
+}
@@ -0,0 +1,36 @@
+package openfaas
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"net/http"
+	"time"
+)
+
+//The image is pulled from its registry, so there are no archives to delete
+func (Client) DeleteFunction(cfg shared.Config, d shared.Deployment, removeArchive bool) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionDelete
+
+	client, err := newAPIClient(cfg, d.Region)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	shared.Log(shared.ProviderOpenFaaS, fmt.Sprintf("Started deleting function %v in region %v", d.Name, d.Region))
+	request := map[string]string{"functionName": d.Name, "namespace": client.credentials.Namespace}
+	err = client.request(http.MethodDelete, client.systemURL("/system/functions", nil), request, nil)
+	if isNotFound(err) {
+		shared.Log(shared.ProviderOpenFaaS, fmt.Sprintf("Function %v in region %v does not exist, nothing to delete", d.Name, d.Region))
+		result.Duration = time.Since(start)
+		return result
+	}
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to delete function %v, Error: %v", d.Name, err), start)
+	}
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderOpenFaaS, fmt.Sprintf("Finished deleting function %v in region %v, took %s", d.Name, d.Region, result.Duration))
+	return result
+}
@@ -0,0 +1,15 @@
+package openfaas
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"time"
+)
+
+//OpenFaaS only knows the image a function currently runs, so previous versions are restored by deploying their image again
+func (Client) RollbackFunction(cfg shared.Config, d shared.Deployment, requestedVersion string) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionRollback
+	return result.Fail(fmt.Errorf("%v does not keep previous versions of functions, deploy their image instead", shared.ProviderOpenFaaS), start)
+}
@@ -0,0 +1,41 @@
+package openfaas
+
+import (
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+	"regexp"
+)
+
+//Tags of functions are Kubernetes annotations, whose keys are a name of at most 63 characters with an optional DNS subdomain as prefix
+var annotationKeyPattern = regexp.MustCompile(`^([a-z0-9]([-a-z0-9.]*[a-z0-9])?/)?[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+
+//Limits of the default configuration of OpenFaaS, the runtime is the image of the function
+var limits = shared.ProviderLimits{
+	MinMemory:  64,
+	MaxMemory:  8192,
+	MinTimeout: 1,
+	MaxTimeout: 900,
+	//The watchdog of the image reads the timeouts from these variables, which GoDeploy sets
+	ReservedEnvironment: []string{"read_timeout", "write_timeout", "exec_timeout"},
+	//Other event sources need connectors that GoDeploy does not create
+	TriggerTypes:      []shared.TriggerType{shared.TriggerHTTP},
+	MinInstancesLimit: "maxInstances",
+}
+
+func (Client) Validate(section string, key string, node *yaml.Node, report shared.ValidationReporter) {
+	if key != "tags" {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		if key == "" || valueNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		if key == shared.OpenFaaSVersionAnnotation {
+			report(keyNode, "tag %v is an annotation set by GoDeploy on %v", key, shared.ProviderOpenFaaS)
+		} else if !annotationKeyPattern.MatchString(key) {
+			report(keyNode, "tag %v is not a valid annotation key on %v, keys are names of at most 63 letters, digits, dashes, underscores and dots, optionally prefixed with a DNS subdomain and /", key, shared.ProviderOpenFaaS)
+		}
+	}
+}
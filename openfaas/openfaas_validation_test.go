@@ -0,0 +1,22 @@
+package openfaas
+
+import (
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestValidateTags(t *testing.T) {
+	var tags yaml.Node
+	content := "team: x\nexample.com/owner: y\n" + shared.OpenFaaSVersionAnnotation + ": z\nnot/a/key: w\n-invalid: v\n"
+	if err := yaml.Unmarshal([]byte(content), &tags); err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	Client{}.Validate("function", "tags", tags.Content[0], func(node *yaml.Node, format string, args ...interface{}) {
+		lines = append(lines, node.Line)
+	})
+	if len(lines) != 3 || lines[0] != 3 || lines[1] != 4 || lines[2] != 5 {
+		t.Errorf("problems reported in lines %v, want 3, 4 and 5", lines)
+	}
+}
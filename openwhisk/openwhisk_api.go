@@ -0,0 +1,194 @@
+package openwhisk
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//Client for the REST API of the OpenWhisk cluster of a region, authenticated with the auth key of the credentials
+type apiClient struct {
+	credentials credentials
+	host        string
+	http        *http.Client
+}
+
+//Error response of the OpenWhisk API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("status %v: %v", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+//Action of the OpenWhisk API, only the fields used by GoDeploy are mapped
+type action struct {
+	Namespace   string       `json:"namespace,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Version     string       `json:"version,omitempty"`
+	Exec        actionExec   `json:"exec"`
+	Limits      actionLimits `json:"limits"`
+	Parameters  []keyValue   `json:"parameters,omitempty"`
+	Annotations []keyValue   `json:"annotations,omitempty"`
+}
+
+//Code of an action, zip archives are sent as base64 encoded binary code
+type actionExec struct {
+	Kind   string `json:"kind"`
+	Code   string `json:"code,omitempty"`
+	Binary bool   `json:"binary,omitempty"`
+	Main   string `json:"main,omitempty"`
+}
+
+//Timeout in milliseconds and memory in MB
+type actionLimits struct {
+	Timeout int32 `json:"timeout,omitempty"`
+	Memory  int32 `json:"memory,omitempty"`
+}
+
+//Parameter or annotation, parameters marked as init are passed as environment variables
+type keyValue struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	Init  bool        `json:"init,omitempty"`
+}
+
+//Record of a single invocation of an action
+type activation struct {
+	ActivationID string `json:"activationId"`
+	Response     struct {
+		Status  string          `json:"status"`
+		Success bool            `json:"success"`
+		Result  json.RawMessage `json:"result"`
+	} `json:"response"`
+	Logs []string `json:"logs"`
+}
+
+//Returns a client for the cluster of the region, regions without an API host in the credentials file are unknown
+func newAPIClient(cfg shared.Config, region string) (*apiClient, error) {
+	c, ok := cfg.Credentials.Providers[shared.ProviderOpenWhisk].(credentials)
+	if !ok {
+		return nil, fmt.Errorf("credentials of %v are not loaded", shared.ProviderOpenWhisk)
+	}
+	host, ok := c.APIHosts[region]
+	if !ok {
+		return nil, fmt.Errorf("unknown region %v of %v, add the API host of its cluster to %v in the credentials file", region, shared.ProviderOpenWhisk, shared.OpenWhiskAPIHostsKey)
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: c.Insecure}
+	return &apiClient{credentials: c, host: strings.TrimSuffix(host, "/"), http: &http.Client{Transport: transport}}, nil
+}
+
+//Returns the regions of all clusters in the credentials file in alphabetical order
+func getRegions(cfg shared.Config) []string {
+	c, _ := cfg.Credentials.Providers[shared.ProviderOpenWhisk].(credentials)
+	var regions []string
+	for region := range c.APIHosts {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+//Returns the URL of a collection of the namespace, e.g. actions, or of one of its entities
+func (c *apiClient) namespaceURL(collection string, name string, query url.Values) string {
+	requestURL := fmt.Sprintf("%v/api/v1/namespaces/%v/%v", c.host, url.PathEscape(c.credentials.Namespace), collection)
+	if name != "" {
+		requestURL += "/" + url.PathEscape(name)
+	}
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	return requestURL
+}
+
+//Sends the request and returns the response regardless of its status, JSON request bodies are encoded from in
+func (c *apiClient) do(method string, requestURL string, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	switch value := in.(type) {
+	case nil:
+	case []byte:
+		body = bytes.NewReader(value)
+	default:
+		content, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize request to %v, Error: %v", requestURL, err)
+		}
+		body = bytes.NewReader(content)
+	}
+
+	request, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request to %v, Error: %v", requestURL, err)
+	}
+	user, key, _ := strings.Cut(c.credentials.Auth, ":")
+	request.SetBasicAuth(user, key)
+	request.Header.Set("Content-Type", "application/json")
+	return c.http.Do(request)
+}
+
+//Sends the request and decodes the response into out, responses with an error status are returned as apiError
+func (c *apiClient) request(method string, requestURL string, in interface{}, out interface{}) error {
+	response, err := c.do(method, requestURL, in)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response of %v, Error: %v", requestURL, err)
+	}
+	if response.StatusCode >= 400 {
+		return parseError(response.StatusCode, content)
+	}
+	if out == nil || len(content) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("unable to parse response of %v, Error: %v", requestURL, err)
+	}
+	return nil
+}
+
+func readError(response *http.Response) error {
+	content, _ := io.ReadAll(response.Body)
+	return parseError(response.StatusCode, content)
+}
+
+//OpenWhisk reports errors as JSON with an error message, other responses are returned as they are
+func parseError(statusCode int, content []byte) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(content))
+	if json.Unmarshal(content, &body) == nil && body.Error != "" {
+		message = body.Error
+	}
+	return &apiError{StatusCode: statusCode, Message: message}
+}
+
+//Returns the action without its code
+func (c *apiClient) getAction(name string) (action, error) {
+	var a action
+	err := c.request(http.MethodGet, c.namespaceURL("actions", name, url.Values{"code": {"false"}}), nil, &a)
+	return a, err
+}
@@ -0,0 +1,154 @@
+package openwhisk
+
+import (
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"godeploy/shared/providertest"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testAuth = "user:key"
+
+//In memory stand-in for the actions API of an OpenWhisk namespace
+type fakeOpenWhisk struct {
+	actions map[string]action
+	//Queries of the requests listing the actions, in the order they were received
+	listQueries []string
+	//Number of PUT requests by action name
+	puts map[string]int
+}
+
+//Starts a fake OpenWhisk cluster, the returned config holds credentials for it in the region onprem
+func newFakeOpenWhisk(t *testing.T) (*fakeOpenWhisk, shared.Config) {
+	fake := &fakeOpenWhisk{actions: map[string]action{}, puts: map[string]int{}}
+	server := providertest.NewServer(t, http.HandlerFunc(fake.serve))
+	return fake, newTestConfig(server.URL)
+}
+
+func newTestConfig(host string) shared.Config {
+	return shared.Config{
+		Region: "onprem",
+		Credentials: shared.CredentialsHolder{Providers: map[shared.ProviderName]interface{}{
+			shared.ProviderOpenWhisk: credentials{Auth: testAuth, Namespace: "guest", APIHosts: map[string]string{"onprem": host}},
+		}},
+	}
+}
+
+//Returns a deployment of the test function as Python action in the region onprem
+func newTestDeployment(t *testing.T, version string, environment map[string]string) shared.Deployment {
+	d := providertest.NewDeployment(t, shared.ProviderOpenWhisk, "onprem", version, environment)
+	d.Runtime = "python:3"
+	d.HandlerFunction = "main"
+	return d
+}
+
+func (f *fakeOpenWhisk) serve(w http.ResponseWriter, r *http.Request) {
+	if user, key, _ := r.BasicAuth(); user+":"+key != testAuth {
+		providertest.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "The supplied authentication is invalid."})
+		return
+	}
+	collection := "/api/v1/namespaces/guest/actions"
+	switch {
+	case r.URL.Path == collection && r.Method == http.MethodGet:
+		f.listActions(w, r)
+	case strings.HasPrefix(r.URL.Path, collection+"/"):
+		name := strings.TrimPrefix(r.URL.Path, collection+"/")
+		existing, ok := f.actions[name]
+		switch {
+		case r.Method == http.MethodPut:
+			f.putAction(w, r, name)
+		case !ok:
+			providertest.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "The requested resource does not exist."})
+		case r.Method == http.MethodGet:
+			existing.Exec.Code = ""
+			providertest.WriteJSON(w, http.StatusOK, existing)
+		case r.Method == http.MethodDelete:
+			delete(f.actions, name)
+			providertest.WriteJSON(w, http.StatusOK, existing)
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+//Existing actions are only replaced with overwrite=true, every update increases the patch version like OpenWhisk does
+func (f *fakeOpenWhisk) putAction(w http.ResponseWriter, r *http.Request, name string) {
+	existing, ok := f.actions[name]
+	if ok && r.URL.Query().Get("overwrite") != "true" {
+		providertest.WriteJSON(w, http.StatusConflict, map[string]string{"error": "resource already exists"})
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var a action
+	if err := json.Unmarshal(body, &a); err != nil {
+		providertest.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	a.Namespace = "guest"
+	a.Name = name
+	a.Version = "0.0.1"
+	if ok {
+		patch, _ := strconv.Atoi(strings.TrimPrefix(existing.Version, "0.0."))
+		a.Version = fmt.Sprintf("0.0.%v", patch+1)
+	}
+	f.actions[name] = a
+	f.puts[name]++
+	providertest.WriteJSON(w, http.StatusOK, a)
+}
+
+func (f *fakeOpenWhisk) listActions(w http.ResponseWriter, r *http.Request) {
+	f.listQueries = append(f.listQueries, r.URL.RawQuery)
+
+	var names []string
+	for name := range f.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+	actions := []action{}
+	for i := skip; i < len(names) && i < skip+limit; i++ {
+		actions = append(actions, f.actions[names[i]])
+	}
+	providertest.WriteJSON(w, http.StatusOK, actions)
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`{"error":"The requested resource does not exist.","code":"abc"}`, "status 404: The requested resource does not exist."},
+		{"Not Found\n", "status 404: Not Found"},
+	}
+	for _, test := range tests {
+		err := parseError(http.StatusNotFound, []byte(test.content))
+		if err.Error() != test.want {
+			t.Errorf("parseError(%q) = %q, want %q", test.content, err, test.want)
+		}
+		if !isNotFound(err) {
+			t.Errorf("isNotFound(parseError(%q)) = false, want true", test.content)
+		}
+	}
+}
+
+func TestNamespaceURL(t *testing.T) {
+	client := &apiClient{credentials: credentials{Namespace: "my space"}, host: "https://whisk.test"}
+
+	if got, want := client.namespaceURL("actions", "", nil), "https://whisk.test/api/v1/namespaces/my%20space/actions"; got != want {
+		t.Errorf("namespaceURL of collection = %v, want %v", got, want)
+	}
+	got := client.namespaceURL("actions", "hello", map[string][]string{"overwrite": {"true"}})
+	if want := "https://whisk.test/api/v1/namespaces/my%20space/actions/hello?overwrite=true"; got != want {
+		t.Errorf("namespaceURL of action = %v, want %v", got, want)
+	}
+}
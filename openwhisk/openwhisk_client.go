@@ -0,0 +1,203 @@
+package openwhisk
+
+import (
+	"encoding/json"
+	"fmt"
+	"godeploy/shared"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Implementation of shared.Client for Apache OpenWhisk, functions are deployed as actions of the namespace in every region
+type Client struct{}
+
+//Maximum number of entities returned by a single list request
+const listLimit = 200
+
+//Actions are created or replaced depending on whether they exist, so both operations are the same
+func (Client) CreateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d)
+}
+
+func (Client) UpdateFunction(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	return deploy(cfg, d)
+}
+
+//Lists the actions of the namespace in the given region, or in all regions of the credentials file if no region is given
+func (Client) ListFunctions(cfg shared.Config) ([]shared.Function, error) {
+	regions := []string{cfg.Region}
+	if cfg.Region == "" {
+		regions = getRegions(cfg)
+	}
+
+	var f []shared.Function
+	for _, region := range regions {
+		client, err := newAPIClient(cfg, region)
+		if err != nil {
+			return nil, err
+		}
+
+		for skip := 0; ; skip += listLimit {
+			var actions []action
+			query := url.Values{"limit": {strconv.Itoa(listLimit)}, "skip": {strconv.Itoa(skip)}}
+			err = client.request(http.MethodGet, client.namespaceURL("actions", "", query), nil, &actions)
+			if err != nil {
+				return nil, fmt.Errorf("unable to list actions in region %v, Error: %v", region, err)
+			}
+			for _, a := range actions {
+				f = append(f, mapAction(a, region))
+			}
+			if len(actions) < listLimit {
+				break
+			}
+		}
+	}
+	return f, nil
+}
+
+func (Client) GetFunction(cfg shared.Config, name string) (*shared.Function, error) {
+	client, err := newAPIClient(cfg, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := client.getAction(name)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get action %v in region %v, Error: %v", name, cfg.Region, err)
+	}
+
+	f := mapAction(a, cfg.Region)
+	return &f, nil
+}
+
+func (Client) DesiredFunction(d shared.Deployment) shared.Function {
+	return shared.Function{
+		Name:        d.Name,
+		Provider:    shared.ProviderOpenWhisk,
+		Region:      d.Region,
+		Runtime:     d.Runtime,
+		Handler:     getMain(d),
+		MemorySize:  d.MemorySize,
+		Timeout:     d.Timeout,
+		Environment: d.Environment,
+	}
+}
+
+//Invokes the action blocking, OpenWhisk only waits a limited time for the result and returns the activation ID of longer invocations.
+//Errors are returned in the invocation, so a failing region does not stop the invocations of the others
+func (Client) InvokeFunction(cfg shared.Config, name string, payload []byte) shared.Invocation {
+	client, err := newAPIClient(cfg, cfg.Region)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: err.Error()}
+	}
+
+	requestURL := client.namespaceURL("actions", name, url.Values{"blocking": {"true"}, "result": {"false"}})
+	response, err := client.do(http.MethodPost, requestURL, payload)
+	if err != nil {
+		return shared.Invocation{Status: "FAILED", Error: fmt.Sprintf("unable to invoke action %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+	defer response.Body.Close()
+
+	//Failed invocations are reported with an error status together with their activation
+	if response.StatusCode >= 400 && response.StatusCode != http.StatusBadGateway {
+		err = readError(response)
+		return shared.Invocation{Status: fmt.Sprint(response.StatusCode), Error: fmt.Sprintf("unable to invoke action %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+	var result activation
+	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return shared.Invocation{Status: fmt.Sprint(response.StatusCode), Error: fmt.Sprintf("unable to parse activation of action %v in region %v, Error: %v", name, cfg.Region, err)}
+	}
+
+	if result.Response.Status == "" {
+		return shared.Invocation{Status: "ACCEPTED", Body: fmt.Sprintf("activation %v is still running", result.ActivationID)}
+	}
+	invocation := shared.Invocation{
+		Status: result.Response.Status,
+		Body:   string(result.Response.Result),
+	}
+	if !result.Response.Success {
+		invocation.Error = string(result.Response.Result)
+	}
+	return invocation
+}
+
+//Returns the log lines of all activations of the action since the given time
+func (Client) GetLogs(cfg shared.Config, name string, since time.Time) ([]shared.LogEntry, error) {
+	client, err := newAPIClient(cfg, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []shared.LogEntry
+	for skip := 0; ; skip += listLimit {
+		var activations []activation
+		query := url.Values{
+			"name":  {name},
+			"since": {strconv.FormatInt(since.UnixMilli(), 10)},
+			"docs":  {"true"},
+			"limit": {strconv.Itoa(listLimit)},
+			"skip":  {strconv.Itoa(skip)},
+		}
+		err = client.request(http.MethodGet, client.namespaceURL("activations", "", query), nil, &activations)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get logs of action %v in region %v, Error: %v", name, cfg.Region, err)
+		}
+
+		for _, a := range activations {
+			for _, line := range a.Logs {
+				if entry, ok := parseLogLine(line); ok && !entry.Timestamp.Before(since) {
+					entry.Region = cfg.Region
+					entries = append(entries, entry)
+				}
+			}
+		}
+		if len(activations) < listLimit {
+			break
+		}
+	}
+
+	//Activations are listed from the newest to the oldest
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, nil
+}
+
+//Parses a log line of an activation, which starts with its timestamp followed by the stream, e.g. 2022-03-01T12:00:00.123Z stdout: message
+func parseLogLine(line string) (shared.LogEntry, bool) {
+	timestamp, message, _ := strings.Cut(line, " ")
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return shared.LogEntry{}, false
+	}
+	if _, text, ok := strings.Cut(message, ": "); ok {
+		message = text
+	}
+	return shared.LogEntry{Timestamp: t, Provider: shared.ProviderOpenWhisk, Message: message}, true
+}
+
+func mapAction(a action, region string) shared.Function {
+	f := shared.Function{
+		Name:       a.Name,
+		Provider:   shared.ProviderOpenWhisk,
+		Region:     region,
+		Runtime:    a.Exec.Kind,
+		Handler:    a.Exec.Main,
+		MemorySize: a.Limits.Memory,
+		Timeout:    a.Limits.Timeout / 1000,
+	}
+	for _, parameter := range a.Parameters {
+		if parameter.Init {
+			if f.Environment == nil {
+				f.Environment = make(map[string]string)
+			}
+			f.Environment[parameter.Key] = fmt.Sprint(parameter.Value)
+		}
+	}
+	return f
+}
@@ -0,0 +1,183 @@
+package openwhisk
+
+import (
+	"encoding/base64"
+	"fmt"
+	"godeploy/shared"
+	"godeploy/shared/providertest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	fake, cfg := newFakeOpenWhisk(t)
+
+	providertest.Lifecycle{
+		Client: Client{},
+		Config: cfg,
+		Deployment: func(version string) shared.Deployment {
+			return newTestDeployment(t, version, map[string]string{"VERSION": version})
+		},
+		AfterDeploy: func(t *testing.T, d shared.Deployment, result shared.DeploymentResult) {
+			a := fake.actions[d.Name]
+			if result.Function != "/guest/"+d.Name {
+				t.Errorf("function = %v, want /guest/%v", result.Function, d.Name)
+			}
+			//Actions are replaced with every deployment, which OpenWhisk counts in their version
+			if want := fmt.Sprintf("0.0.%v", fake.puts[d.Name]); a.Version != want {
+				t.Errorf("version of action = %v, want %v", a.Version, want)
+			}
+			if code, _ := base64.StdEncoding.DecodeString(a.Exec.Code); !a.Exec.Binary || string(code) != "archive "+d.Version {
+				t.Errorf("code of action = %q, want the binary archive of %v", code, d.Version)
+			}
+			if a.Limits != (actionLimits{Timeout: d.Timeout * 1000, Memory: d.MemorySize}) {
+				t.Errorf("limits = %+v, want %v ms and %v MB", a.Limits, d.Timeout*1000, d.MemorySize)
+			}
+		},
+	}.Run(t)
+}
+
+func TestMapAction(t *testing.T) {
+	a := action{
+		Name:   "hello",
+		Exec:   actionExec{Kind: "python:3", Main: "main"},
+		Limits: actionLimits{Timeout: 30000, Memory: 256},
+		//Default parameters are not environment variables
+		Parameters: []keyValue{{Key: "LOG_LEVEL", Value: "info", Init: true}, {Key: "greeting", Value: "hi"}},
+	}
+
+	f := mapAction(a, "onprem")
+	if f.Timeout != 30 || f.MemorySize != 256 || f.Handler != "main" || f.Region != "onprem" {
+		t.Errorf("function = %+v, want main in onprem with 30s and 256 MB", f)
+	}
+	if len(f.Environment) != 1 || f.Environment["LOG_LEVEL"] != "info" {
+		t.Errorf("environment = %v, want only LOG_LEVEL", f.Environment)
+	}
+}
+
+func TestListFunctionsPagesWithListLimit(t *testing.T) {
+	fake, cfg := newFakeOpenWhisk(t)
+	for i := 0; i <= listLimit; i++ {
+		name := fmt.Sprintf("action-%03d", i)
+		fake.actions[name] = action{Namespace: "guest", Name: name, Exec: actionExec{Kind: "python:3"}}
+	}
+
+	functions, err := Client{}.ListFunctions(cfg)
+	if err != nil {
+		t.Fatalf("ListFunctions failed: %v", err)
+	}
+	if len(functions) != listLimit+1 {
+		t.Fatalf("ListFunctions returned %v actions, want %v", len(functions), listLimit+1)
+	}
+	want := []string{fmt.Sprintf("limit=%v&skip=0", listLimit), fmt.Sprintf("limit=%v&skip=%v", listLimit, listLimit)}
+	if len(fake.listQueries) != len(want) || fake.listQueries[0] != want[0] || fake.listQueries[1] != want[1] {
+		t.Errorf("list queries = %v, want %v", fake.listQueries, want)
+	}
+	if functions[listLimit].Name != fmt.Sprintf("action-%03d", listLimit) || functions[listLimit].Region != "onprem" {
+		t.Errorf("last function = %+v, want action-%03d in onprem", functions[listLimit], listLimit)
+	}
+}
+
+//Failures other than a missing action are returned, so status can report them per target
+func TestGetFunctionReturnsErrors(t *testing.T) {
+	server := providertest.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		providertest.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "database unavailable"})
+	}))
+
+	f, err := Client{}.GetFunction(newTestConfig(server.URL), "hello")
+	if f != nil || err == nil {
+		t.Errorf("GetFunction = %+v, %v, want an error", f, err)
+	}
+}
+
+func TestInvokeFunction(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       shared.Invocation
+	}{
+		{
+			name:       "success",
+			statusCode: http.StatusOK,
+			body:       `{"activationId":"a1","response":{"status":"success","success":true,"result":{"greeting":"hi"}}}`,
+			want:       shared.Invocation{Status: "success", Body: `{"greeting":"hi"}`},
+		},
+		{
+			//Failed actions are reported with 502 together with their activation
+			name:       "failed activation",
+			statusCode: http.StatusBadGateway,
+			body:       `{"activationId":"a2","response":{"status":"application error","success":false,"result":{"error":"boom"}}}`,
+			want:       shared.Invocation{Status: "application error", Body: `{"error":"boom"}`, Error: `{"error":"boom"}`},
+		},
+		{
+			name:       "still running",
+			statusCode: http.StatusAccepted,
+			body:       `{"activationId":"a3"}`,
+			want:       shared.Invocation{Status: "ACCEPTED", Body: "activation a3 is still running"},
+		},
+		{
+			name:       "missing action",
+			statusCode: http.StatusNotFound,
+			body:       `{"error":"The requested resource does not exist."}`,
+			want:       shared.Invocation{Status: "404", Error: "unable to invoke action hello in region onprem, Error: status 404: The requested resource does not exist."},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/api/v1/namespaces/guest/actions/hello" || r.URL.Query().Get("blocking") != "true" {
+					t.Errorf("unexpected request %v %v", r.Method, r.URL)
+				}
+				w.WriteHeader(test.statusCode)
+				fmt.Fprint(w, test.body)
+			}))
+			defer server.Close()
+
+			got := Client{}.InvokeFunction(newTestConfig(server.URL), "hello", []byte(`{"name":"x"}`))
+			if got != test.want {
+				t.Errorf("InvokeFunction = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestInvokeFunctionUnknownRegion(t *testing.T) {
+	cfg := newTestConfig("http://127.0.0.1:0")
+	cfg.Region = "unknown"
+
+	got := Client{}.InvokeFunction(cfg, "hello", nil)
+	if got.Status != "FAILED" || got.Error == "" {
+		t.Errorf("InvokeFunction in unknown region = %+v, want a failed invocation", got)
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	timestamp := time.Date(2022, 3, 1, 12, 0, 0, 123000000, time.UTC)
+	tests := []struct {
+		line    string
+		message string
+		ok      bool
+	}{
+		{"2022-03-01T12:00:00.123Z stdout: hello world", "hello world", true},
+		{"2022-03-01T12:00:00.123Z stderr: failed: timeout", "failed: timeout", true},
+		{"2022-03-01T12:00:00.123Z message without stream", "message without stream", true},
+		{"not a log line", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		entry, ok := parseLogLine(test.line)
+		if ok != test.ok {
+			t.Errorf("parseLogLine(%q) ok = %v, want %v", test.line, ok, test.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if entry.Message != test.message || !entry.Timestamp.Equal(timestamp) || entry.Provider != shared.ProviderOpenWhisk {
+			t.Errorf("parseLogLine(%q) = %+v, want message %q at %v", test.line, entry, test.message, timestamp)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package openwhisk
+
+import (
+	"encoding/base64"
+	"fmt"
+	"godeploy/shared"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Maximum size of the code of an action in the default configuration of OpenWhisk
+const maxCodeSize = 48 * 1024 * 1024
+
+//The code is stored within the action, so the archive is only checked and read when the action is created
+func (Client) UploadArchive(cfg shared.Config, d shared.Deployment) (shared.Deployment, error) {
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	if shared.IsAWSObjectURI(d.Archive) || shared.IsGoogleObjectURI(d.Archive) || shared.IsAzureObjectURI(d.Archive) {
+		return d, fmt.Errorf("archive %v is stored at another provider, %v only supports local archives", d.Archive, shared.ProviderOpenWhisk)
+	}
+	info, err := os.Stat(d.Archive)
+	if err != nil {
+		return d, fmt.Errorf("unable to read archive %v, Error: %v", d.Archive, err)
+	}
+	if size := info.Size() * 4 / 3; size > maxCodeSize {
+		return d, fmt.Errorf("archive %v is too large for %v, the encoded code of actions is limited to %v MB", d.Archive, shared.ProviderOpenWhisk, maxCodeSize/1024/1024)
+	}
+	return d, nil
+}
+
+//Creates the action or replaces it if it already exists
+func deploy(cfg shared.Config, d shared.Deployment) shared.DeploymentResult {
+	start := time.Now()
+	if d.Version == "" {
+		d.Version = shared.NewVersion()
+	}
+	result := shared.NewDeploymentResult(d)
+
+	client, err := newAPIClient(cfg, d.Region)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+	content, err := os.ReadFile(d.Archive)
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to read archive %v, Error: %v", d.Archive, err), start)
+	}
+
+	result.Action = shared.ActionUpdate
+	if _, err = client.getAction(d.Name); isNotFound(err) {
+		result.Action = shared.ActionCreate
+	} else if err != nil {
+		return result.Fail(fmt.Errorf("unable to get action %v, Error: %v", d.Name, err), start)
+	}
+	shared.Log(shared.ProviderOpenWhisk, fmt.Sprintf("Started deploying action %v in region %v with %v MB memory", d.Name, d.Region, d.MemorySize))
+
+	a := action{
+		Exec: actionExec{
+			Kind:   d.Runtime,
+			Code:   base64.StdEncoding.EncodeToString(content),
+			Binary: true,
+			Main:   getMain(d),
+		},
+		Limits:      actionLimits{Timeout: d.Timeout * 1000, Memory: d.MemorySize},
+		Parameters:  getParameters(d),
+		Annotations: getAnnotations(d),
+	}
+	var deployed action
+	if err = client.request(http.MethodPut, client.namespaceURL("actions", d.Name, url.Values{"overwrite": {"true"}}), a, &deployed); err != nil {
+		return result.Fail(fmt.Errorf("unable to deploy action %v, Error: %v", d.Name, err), start)
+	}
+	//Actions are identified by their fully qualified name /<NAMESPACE>/<NAME>
+	result.Function = fmt.Sprintf("/%v/%v", deployed.Namespace, deployed.Name)
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderOpenWhisk, fmt.Sprintf("Finished deploying action %v in region %v as version %v, took %s", result.Function, d.Region, deployed.Version, result.Duration))
+	return result
+}
+
+//OpenWhisk calls the function given as main, Java actions are addressed by their class and method
+func getMain(d shared.Deployment) string {
+	if strings.HasPrefix(d.Runtime, "java") {
+		return fmt.Sprintf("%v#%v", d.HandlerFile, d.HandlerFunction)
+	}
+	return d.HandlerFunction
+}
+
+//Environment variables are passed as init parameters, which the runtimes set as environment variables of the action
+func getParameters(d shared.Deployment) []keyValue {
+	var names []string
+	for name := range d.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parameters []keyValue
+	for _, name := range names {
+		parameters = append(parameters, keyValue{Key: name, Value: d.Environment[name], Init: true})
+	}
+	return parameters
+}
+
+//Tags are applied as annotations, a http trigger exports the action as web action which requires authentication unless it is public
+func getAnnotations(d shared.Deployment) []keyValue {
+	var keys []string
+	for key := range d.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var annotations []keyValue
+	for _, key := range keys {
+		annotations = append(annotations, keyValue{Key: key, Value: d.Tags[key]})
+	}
+	annotations = append(annotations, keyValue{Key: shared.OpenWhiskVersionAnnotation, Value: d.Version})
+
+	for _, trigger := range d.Triggers {
+		if trigger.Type == shared.TriggerHTTP {
+			annotations = append(annotations,
+				keyValue{Key: "web-export", Value: true},
+				keyValue{Key: "final", Value: true},
+				keyValue{Key: "require-whisk-auth", Value: !trigger.Public})
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,51 @@
+package openwhisk
+
+import (
+	"godeploy/shared"
+	"testing"
+)
+
+func TestDeployUnknownRegion(t *testing.T) {
+	_, cfg := newFakeOpenWhisk(t)
+	d := newTestDeployment(t, "v1", nil)
+	d.Region = "unknown"
+
+	result := Client{}.CreateFunction(cfg, d)
+	if result.Err == nil {
+		t.Fatal("CreateFunction in unknown region succeeded")
+	}
+}
+
+func TestGetAnnotations(t *testing.T) {
+	d := shared.Deployment{
+		Version:  "v1",
+		Tags:     map[string]string{"team": "x"},
+		Triggers: []shared.Trigger{{Type: shared.TriggerHTTP, Public: true}},
+	}
+
+	got := getAnnotations(d)
+	want := []keyValue{
+		{Key: "team", Value: "x"},
+		{Key: shared.OpenWhiskVersionAnnotation, Value: "v1"},
+		{Key: "web-export", Value: true},
+		{Key: "final", Value: true},
+		{Key: "require-whisk-auth", Value: false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("annotations = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("annotation %v = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGetMain(t *testing.T) {
+	if got := getMain(shared.Deployment{Runtime: "java:8", HandlerFile: "com.example.Hello", HandlerFunction: "main"}); got != "com.example.Hello#main" {
+		t.Errorf("main of Java action = %v, want com.example.Hello#main", got)
+	}
+	if got := getMain(shared.Deployment{Runtime: "nodejs:18", HandlerFunction: "handler"}); got != "handler" {
+		t.Errorf("main of Node.js action = %v, want handler", got)
+	}
+}
@@ -0,0 +1,83 @@
+package openwhisk
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"godeploy/shared"
+)
+
+//Namespace of the subject the auth key belongs to
+const defaultNamespace = "_"
+
+//Auth key and the API hosts of all clusters, read from openwhisk-credentials.yaml
+type credentials struct {
+	Auth      string
+	Namespace string
+	//API hosts by region, every region is a separate cluster
+	APIHosts map[string]string
+	//Skips the verification of TLS certificates, e.g. for clusters with self-signed certificates
+	Insecure bool
+}
+
+func init() {
+	shared.RegisterProvider(Client{})
+}
+
+func (Client) Name() shared.ProviderName {
+	return shared.ProviderOpenWhisk
+}
+
+func (Client) Limits() shared.ProviderLimits {
+	return limits
+}
+
+func (Client) DefaultRegion() string {
+	return shared.DefaultOpenWhiskRegion
+}
+
+func (Client) DefaultRuntime() string {
+	return "python:3"
+}
+
+func (Client) CredentialsFile() string {
+	return shared.OpenWhiskCredentialsFile
+}
+
+func (Client) CredentialsTemplate() string {
+	return shared.OpenWhiskAuthKey + `: "<UUID>:<KEY>"
+` + shared.OpenWhiskNamespaceKey + `: "` + defaultNamespace + `"
+` + shared.OpenWhiskInsecureKey + `: false
+` + shared.OpenWhiskAPIHostsKey + `:
+  ` + shared.DefaultOpenWhiskRegion + `: "https://<API_HOST>"
+`
+}
+
+//The keys of the credentials file are read from the configuration, the API host of a region is looked up when it is used
+func (Client) LoadCredentials(file string, credentialsHolder *shared.CredentialsHolder) error {
+	c := credentials{
+		Auth:      viper.GetString(shared.OpenWhiskAuthKey),
+		Namespace: viper.GetString(shared.OpenWhiskNamespaceKey),
+		APIHosts:  viper.GetStringMapString(shared.OpenWhiskAPIHostsKey),
+		Insecure:  viper.GetBool(shared.OpenWhiskInsecureKey),
+	}
+	if c.Auth == "" {
+		return fmt.Errorf("missing key %v in credentials file {%v}", shared.OpenWhiskAuthKey, file)
+	}
+	if len(c.APIHosts) == 0 {
+		return fmt.Errorf("missing key %v in credentials file {%v}, it maps every region to the API host of its cluster", shared.OpenWhiskAPIHostsKey, file)
+	}
+	if c.Namespace == "" {
+		c.Namespace = defaultNamespace
+	}
+
+	if credentialsHolder.Providers == nil {
+		credentialsHolder.Providers = make(map[shared.ProviderName]interface{})
+	}
+	credentialsHolder.Providers[shared.ProviderOpenWhisk] = c
+	return nil
+}
+
+//OpenWhisk stores the code within the action, so archives are never stored at the provider
+func (Client) IsArchiveURI(archive string) bool {
+	return false
+}
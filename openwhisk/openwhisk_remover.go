@@ -0,0 +1,35 @@
+package openwhisk
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"net/http"
+	"time"
+)
+
+//The code is part of the action, so there are no archives to delete
+func (Client) DeleteFunction(cfg shared.Config, d shared.Deployment, removeArchive bool) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionDelete
+
+	client, err := newAPIClient(cfg, d.Region)
+	if err != nil {
+		return result.Fail(err, start)
+	}
+
+	shared.Log(shared.ProviderOpenWhisk, fmt.Sprintf("Started deleting action %v in region %v", d.Name, d.Region))
+	err = client.request(http.MethodDelete, client.namespaceURL("actions", d.Name, nil), nil, nil)
+	if isNotFound(err) {
+		shared.Log(shared.ProviderOpenWhisk, fmt.Sprintf("Action %v in region %v does not exist, nothing to delete", d.Name, d.Region))
+		result.Duration = time.Since(start)
+		return result
+	}
+	if err != nil {
+		return result.Fail(fmt.Errorf("unable to delete action %v, Error: %v", d.Name, err), start)
+	}
+
+	result.Duration = time.Since(start)
+	shared.Log(shared.ProviderOpenWhisk, fmt.Sprintf("Finished deleting action %v in region %v, took %s", d.Name, d.Region, result.Duration))
+	return result
+}
@@ -0,0 +1,15 @@
+package openwhisk
+
+import (
+	"fmt"
+	"godeploy/shared"
+	"time"
+)
+
+//OpenWhisk replaces the code of an action with every update and keeps no previous versions, so they can not be restored
+func (Client) RollbackFunction(cfg shared.Config, d shared.Deployment, requestedVersion string) shared.DeploymentResult {
+	start := time.Now()
+	result := shared.NewDeploymentResult(d)
+	result.Action = shared.ActionRollback
+	return result.Fail(fmt.Errorf("%v does not keep previous versions of actions", shared.ProviderOpenWhisk), start)
+}
@@ -0,0 +1,15 @@
+package openwhisk
+
+import (
+	"godeploy/shared"
+	"testing"
+)
+
+func TestRollbackFunctionIsNotSupported(t *testing.T) {
+	_, cfg := newFakeOpenWhisk(t)
+
+	result := Client{}.RollbackFunction(cfg, newTestDeployment(t, "v1", nil), "")
+	if result.Err == nil || result.Action != shared.ActionRollback {
+		t.Errorf("RollbackFunction = %v, %v, want a failed %v", result.Action, result.Err, shared.ActionRollback)
+	}
+}
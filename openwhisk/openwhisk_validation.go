@@ -0,0 +1,37 @@
+package openwhisk
+
+import (
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+)
+
+//Annotations of actions set by GoDeploy, which can not be used as tags
+var reservedAnnotations = []string{shared.OpenWhiskVersionAnnotation, "web-export", "require-whisk-auth", "final"}
+
+//Default limits of an OpenWhisk cluster, runtimes are the kinds of the default runtime manifest
+var limits = shared.ProviderLimits{
+	MinMemory:  128,
+	MaxMemory:  2048,
+	MinTimeout: 1,
+	MaxTimeout: 300,
+	Runtimes: []string{
+		"nodejs:14", "nodejs:16", "nodejs:18",
+		"python:3", "python:3.9", "python:3.10", "python:3.11",
+		"java:8", "go:1.19", "go:1.20", "php:8.0", "php:8.1",
+		"ruby:2.5", "dotnet:3.1", "swift:5.3", "rust:1.34",
+	},
+	ReservedEnvironment: []string{"__OW_"},
+	//Other event sources need feeds and rules that GoDeploy does not create
+	TriggerTypes: []shared.TriggerType{shared.TriggerHTTP},
+}
+
+func (Client) Validate(section string, key string, node *yaml.Node, report shared.ValidationReporter) {
+	if key != "tags" {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if keyNode := node.Content[i]; shared.Contains(reservedAnnotations, keyNode.Value) {
+			report(keyNode, "tag %v is an annotation set by GoDeploy on %v", keyNode.Value, shared.ProviderOpenWhisk)
+		}
+	}
+}
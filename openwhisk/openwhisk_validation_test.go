@@ -0,0 +1,21 @@
+package openwhisk
+
+import (
+	"godeploy/shared"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestValidateTags(t *testing.T) {
+	var tags yaml.Node
+	if err := yaml.Unmarshal([]byte("team: x\nweb-export: y\n"+shared.OpenWhiskVersionAnnotation+": z\n"), &tags); err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	Client{}.Validate("function", "tags", tags.Content[0], func(node *yaml.Node, format string, args ...interface{}) {
+		lines = append(lines, node.Line)
+	})
+	if len(lines) != 2 || lines[0] != 2 || lines[1] != 3 {
+		t.Errorf("problems reported in lines %v, want the annotations in lines 2 and 3", lines)
+	}
+}
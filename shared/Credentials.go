@@ -8,4 +8,6 @@ import (
 type CredentialsHolder struct {
 	AwsCredentials    *aws.Credentials
 	GoogleCredentials *google.Credentials
+	//Credentials of other registered providers, stored by their LoadCredentials
+	Providers map[ProviderName]interface{}
 }
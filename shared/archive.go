@@ -0,0 +1,163 @@
+package shared
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Timestamp used for all archive entries, so the same source always results in the same archive
+var archiveTimestamp = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type Source struct {
+	Path    string   `mapstructure:"path"`
+	Include []string `mapstructure:"include"`
+	Exclude []string `mapstructure:"exclude"`
+}
+
+//Returns the location of the archive built from the source of the given function
+func SourceArchivePath(directory string, name string) string {
+	return filepath.Join(directory, fmt.Sprintf("%v.zip", name))
+}
+
+//Zips the source directory into the given archive, files are sorted and all timestamps are fixed,
+//so the same source produces a byte-identical archive
+func PackageSource(s Source, archive string) {
+	files := getSourceFiles(s, archive)
+
+	err := os.MkdirAll(filepath.Dir(archive), 0755)
+	CheckErr(err, fmt.Sprintf("unable to create directory for archive %v, Error: %v", archive, err))
+
+	f, err := os.Create(archive)
+	CheckErr(err, fmt.Sprintf("unable to create archive %v, Error: %v", archive, err))
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+	for _, file := range files {
+		addArchiveEntry(writer, s.Path, file)
+	}
+	err = writer.Close()
+	CheckErr(err, fmt.Sprintf("unable to write archive %v, Error: %v", archive, err))
+}
+
+//Returns the sorted slash separated paths of all files of the source that are included and not excluded.
+//The directory the archive is written to, the default archive directory and the archive itself are skipped, so previous archives are never packaged
+func getSourceFiles(s Source, archive string) []string {
+	var files []string
+	sourcePath, outputDirectory, archivePath := absolutePath(s.Path), absolutePath(filepath.Dir(archive)), absolutePath(archive)
+	defaultDirectory := filepath.Join(sourcePath, DefaultArchiveDirectory)
+
+	err := filepath.WalkDir(s.Path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && absolutePath(path) != sourcePath && (absolutePath(path) == outputDirectory || absolutePath(path) == defaultDirectory) {
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() || absolutePath(path) == archivePath {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(s.Path, path)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		if len(s.Include) > 0 && !Any(s.Include, func(pattern string) bool { return matchGlob(pattern, relativePath) }) {
+			return nil
+		}
+		if Any(s.Exclude, func(pattern string) bool { return matchGlob(pattern, relativePath) }) {
+			return nil
+		}
+		files = append(files, relativePath)
+		return nil
+	})
+	CheckErr(err, fmt.Sprintf("unable to read source directory %v, Error: %v", s.Path, err))
+
+	sort.Strings(files)
+	return files
+}
+
+func absolutePath(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
+	}
+	return filepath.Clean(path)
+}
+
+func addArchiveEntry(writer *zip.Writer, sourcePath string, file string) {
+	info, err := os.Stat(filepath.Join(sourcePath, filepath.FromSlash(file)))
+	CheckErr(err, fmt.Sprintf("unable to read file %v, Error: %v", file, err))
+
+	header := &zip.FileHeader{
+		Name:     file,
+		Method:   zip.Deflate,
+		Modified: archiveTimestamp,
+	}
+	//Only keep the executable bit, so archives do not depend on the umask of the machine
+	if info.Mode()&0111 != 0 {
+		header.SetMode(0755)
+	} else {
+		header.SetMode(0644)
+	}
+
+	entry, err := writer.CreateHeader(header)
+	CheckErr(err, fmt.Sprintf("unable to add file %v to archive, Error: %v", file, err))
+
+	f, err := os.Open(filepath.Join(sourcePath, filepath.FromSlash(file)))
+	CheckErr(err, fmt.Sprintf("os.Open: %v, Error: %v", file, err))
+	defer f.Close()
+
+	_, err = io.Copy(entry, f)
+	CheckErr(err, fmt.Sprintf("io.Copy: %v", err))
+}
+
+//Matches a slash separated path against a glob pattern supporting *, ? and **.
+//A pattern also matches all files inside a matching directory and patterns without a slash match at any depth
+func matchGlob(pattern string, path string) bool {
+	expression := globToRegexp(strings.TrimSuffix(pattern, "/"))
+
+	segments := strings.Split(path, "/")
+	for i := 1; i <= len(segments); i++ {
+		if expression.MatchString(strings.Join(segments[:i], "/")) {
+			return true
+		}
+		if !strings.Contains(pattern, "/") && expression.MatchString(segments[i-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				sb.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
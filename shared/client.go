@@ -1,13 +1,117 @@
 package shared
 
-import "sync"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 type Client interface {
-	CreateFunction(wg *sync.WaitGroup, cfg Config, d Deployment)
-	UpdateFunction(wg *sync.WaitGroup, cfg Config, d Deployment)
-	ListFunctions(cfg Config) []string
+	//Deploy the function and report the outcome instead of exiting, so other targets can continue
+	CreateFunction(cfg Config, d Deployment) DeploymentResult
+	UpdateFunction(cfg Config, d Deployment) DeploymentResult
+	ListFunctions(cfg Config) ([]Function, error)
+	//Returns the deployed function with the given name or nil if it does not exist, failures are returned so other targets can continue
+	GetFunction(cfg Config, name string) (*Function, error)
+	//Returns the function as it would look like after deploying the given deployment
+	DesiredFunction(d Deployment) Function
+	//Synchronously calls the deployed function with the given payload
+	InvokeFunction(cfg Config, name string, payload []byte) Invocation
+	//Returns the log entries of the deployed function written since the given time, ordered by timestamp
+	GetLogs(cfg Config, name string, since time.Time) ([]LogEntry, error)
 }
 
 //Wrapper for config for different cloud providers
 type Config struct {
+	Region      string
+	Credentials CredentialsHolder
+}
+
+//Function as it is currently deployed at a provider
+type Function struct {
+	Name       string
+	Provider   ProviderName
+	Region     string
+	Runtime    string
+	Handler    string
+	MemorySize int32
+	Timeout    int32
+	//Provider specific hash of the deployed code, empty if unknown
+	CodeHash string
+	//Provider specific state of the function
+	State string
+	//Whether the provider reports the function or its last update as failed
+	Failed      bool
+	Environment map[string]string
+	//Maximum number of instances, 0 if the provider does not support it
+	MaxInstances int32
+	//Minimum number of instances or provisioned concurrency
+	MinInstances int32
+	//Reserved concurrency, 0 if the provider does not support it or none is reserved
+	ReservedConcurrency int32
+}
+
+//Result of a function invocation
+type Invocation struct {
+	Status string
+	Body   string
+	//Error reported by the provider or the function itself, empty if the invocation succeeded
+	Error string
+}
+
+type LogEntry struct {
+	Timestamp time.Time
+	Provider  ProviderName
+	Region    string
+	Message   string
+}
+
+type FieldChange struct {
+	Field   string
+	Current string
+	Desired string
+}
+
+//Compares the configuration of a deployed function with the desired one, unknown code hashes are not compared
+func CompareFunctions(current Function, desired Function) []FieldChange {
+	var changes []FieldChange
+
+	compare := func(field string, c interface{}, d interface{}) {
+		if c != d {
+			changes = append(changes, FieldChange{Field: field, Current: fmt.Sprint(c), Desired: fmt.Sprint(d)})
+		}
+	}
+	compare("memory", current.MemorySize, desired.MemorySize)
+	compare("timeout", current.Timeout, desired.Timeout)
+	compare("runtime", current.Runtime, desired.Runtime)
+	compare("handler", current.Handler, desired.Handler)
+	compare("maxInstances", current.MaxInstances, desired.MaxInstances)
+	compare("minInstances", current.MinInstances, desired.MinInstances)
+	compare("reservedConcurrency", current.ReservedConcurrency, desired.ReservedConcurrency)
+	if desired.CodeHash != "" {
+		compare("code", current.CodeHash, desired.CodeHash)
+	}
+	if changed := changedVariables(current.Environment, desired.Environment); len(changed) > 0 {
+		//Values are not shown, as they may contain secrets
+		changes = append(changes, FieldChange{Field: "environment", Desired: strings.Join(changed, " ")})
+	}
+	return changes
+}
+
+//Returns the sorted names of all environment variables that were added, removed or changed
+func changedVariables(current map[string]string, desired map[string]string) []string {
+	var changed []string
+	for key, value := range desired {
+		if currentValue, ok := current[key]; !ok || currentValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
 }
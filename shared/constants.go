@@ -6,6 +6,9 @@ const DefaultFileExtension = "yaml"
 //Default regions
 const DefaultAWSRegion = "us-east-1"
 const DefaultGoogleRegion = "us-east1"
+const DefaultAzureRegion = "eastus"
+const DefaultOpenWhiskRegion = "default"
+const DefaultOpenFaaSRegion = "default"
 
 //Default serverless function roles
 const DefaultAWSRole = "LabRole"
@@ -16,11 +19,60 @@ const AWSSecretAccessKey = "aws_secret_access_key"
 const AWSSessionTokenKey = "aws_session_token"
 const AWSRoleKey = "role"
 
+//Keys needed for parsing the service principal from azure-credentials.yaml
+const AzureTenantIDKey = "azure_tenant_id"
+const AzureClientIDKey = "azure_client_id"
+const AzureClientSecretKey = "azure_client_secret"
+const AzureSubscriptionIDKey = "azure_subscription_id"
+const AzureResourceGroupKey = "azure_resource_group"
+const AzureStorageAccountKey = "azure_storage_account"
+
+//Keys needed for parsing credentials from openwhisk-credentials.yaml, the API hosts are a mapping of regions to the API host of their cluster
+const OpenWhiskAuthKey = "openwhisk_auth"
+const OpenWhiskNamespaceKey = "openwhisk_namespace"
+const OpenWhiskAPIHostsKey = "openwhisk_apihosts"
+const OpenWhiskInsecureKey = "openwhisk_insecure"
+
+//Keys needed for parsing credentials from openfaas-credentials.yaml, the gateways are a mapping of regions to the gateway of their cluster
+const OpenFaaSUserKey = "openfaas_user"
+const OpenFaaSPasswordKey = "openfaas_password"
+const OpenFaaSNamespaceKey = "openfaas_namespace"
+const OpenFaaSGatewaysKey = "openfaas_gateways"
+const OpenFaaSInsecureKey = "openfaas_insecure"
+
+//Optional keys of azure-credentials.yaml replacing the public Azure endpoints, e.g. with a local stand-in
+const AzureManagementEndpointKey = "azure_management_endpoint"
+const AzureAuthorityEndpointKey = "azure_authority_endpoint"
+const AzureStorageEndpointKey = "azure_storage_endpoint"
+
 //Constants
 const ArchiveBucketName = "godeploy-deployments"
+const DefaultArchiveDirectory = ".godeploy"
 const GoogleProjectID = "project_id"
 const AWSCredentialsFile = "aws-credentials"
 const GoogleCredentialsFile = "google-credentials"
+const AzureCredentialsFile = "azure-credentials"
+const OpenWhiskCredentialsFile = "openwhisk-credentials"
+const OpenFaaSCredentialsFile = "openfaas-credentials"
 const OAuthStorageScope = "https://www.googleapis.com/auth/devstorage.full_control"
 const OAuthFunctionScope = "https://www.googleapis.com/auth/cloud-platform"
 const DefaultMaxFunctionInstances = 5
+
+//Alias pointing to the Lambda version that is currently deployed
+const AWSLiveAlias = "live"
+
+//Prefix of the aliases recording deployments that reused an existing Lambda version, because their code and configuration were unchanged
+const AWSVersionAliasPrefix = "version-"
+
+//Label storing the version of a Cloud Function
+const GoogleVersionLabel = "godeploy-version"
+
+//Tags storing the version and the function name of an Azure Function App
+const AzureVersionTag = "godeploy-version"
+const AzureFunctionTag = "godeploy-function"
+
+//Annotation storing the version of an OpenWhisk action
+const OpenWhiskVersionAnnotation = "godeploy-version"
+
+//Annotation storing the version of an OpenFaaS function
+const OpenFaaSVersionAnnotation = "godeploy-version"
@@ -1,5 +1,13 @@
 package shared
 
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
 type Deployment struct {
 	Archive         string
 	Name            string
@@ -10,23 +18,185 @@ type Deployment struct {
 	HandlerFile     string
 	HandlerFunction string
 	Region          string
-	Bucket          string
-	Key             string
+	//Maximum number of function instances, only supported by Google, Azure and OpenFaaS, 0 uses the default of the provider
+	MaxInstances int32
+	//Instances kept warm, applied as minimum instances on Google and OpenFaaS, as provisioned concurrency of the live alias on AWS and as always ready instances on Azure
+	MinInstances int32
+	//Concurrent executions reserved for the function, only supported by AWS, 0 uses the unreserved concurrency of the account
+	ReservedConcurrency int32
+	Bucket              string
+	Key                 string
+	//Identifies the deployment run, archives are stored and functions are published under this version
+	Version string
+	//Environment variables of the function, including resolved secrets
+	Environment map[string]string
+	//Environment variables of the function mapped to the local environment variables holding their values
+	Secrets map[string]string
+	//Event sources of the function, no trigger only creates a http trigger on Google
+	Triggers []Trigger
+	//Tags of the function and its archives, including the managed-by tag
+	Tags map[string]string
+	//VPC the function is connected to, no network removes it from its VPC
+	Network Network
+}
+
+//Returns a new version, versions are ordered chronologically when sorted.
+//Microseconds are included so deployments started within the same second get distinct versions,
+//they are separated by a dash because dots are not allowed in Google labels
+func NewVersion() string {
+	now := time.Now().UTC()
+	return fmt.Sprintf("%v-%06d", now.Format("20060102-150405"), now.Nanosecond()/int(time.Microsecond))
+}
+
+//Returns the key under which the archive of the given function version is stored in the deployment buckets
+func ArchiveKey(name string, version string) string {
+	return fmt.Sprintf("%v/%v", name, version)
+}
+
+//Returns the deployment without the values of its secrets, so they are never written to the deployment history
+func (d Deployment) WithoutSecrets() Deployment {
+	environment := make(map[string]string)
+	for key, value := range d.Environment {
+		if _, ok := d.Secrets[key]; !ok {
+			environment[key] = value
+		}
+	}
+	d.Environment = environment
+	return d
+}
+
+//Returns the deployment with the values of its secrets read from the local environment variables they are mapped to,
+//together with the local environment variables that are not set
+func (d Deployment) ResolveSecrets() (Deployment, []string) {
+	environment := make(map[string]string)
+	for key, value := range d.Environment {
+		environment[key] = value
+	}
+
+	var missing []string
+	for key, localVariable := range d.Secrets {
+		value, ok := os.LookupEnv(localVariable)
+		if !ok {
+			missing = append(missing, localVariable)
+			continue
+		}
+		environment[key] = value
+	}
+	sort.Strings(missing)
+	d.Environment = environment
+	return d, missing
 }
 
 type DeploymentDto struct {
-	Archive    string     `mapstructure:"archive"`
-	Name       string     `mapstructure:"name"`
-	MemorySize int32      `mapstructure:"memory"`
-	Timeout    int32      `mapstructure:"timeout"`
-	Providers  []Provider `mapstructure:"providers"`
+	Archive      string     `mapstructure:"archive"`
+	Source       *Source    `mapstructure:"source"`
+	Name         string     `mapstructure:"name"`
+	MemorySize   int32      `mapstructure:"memory"`
+	Timeout      int32      `mapstructure:"timeout"`
+	Providers    []Provider `mapstructure:"providers"`
+	MaxInstances int32      `mapstructure:"maxInstances"`
+	MinInstances int32      `mapstructure:"minInstances"`
+	//Only supported by AWS
+	ReservedConcurrency int32 `mapstructure:"reservedConcurrency"`
+	//Environment variables set for the function at every provider
+	Environment map[string]string `mapstructure:"environment"`
+	//Environment variables whose values are read from the given local environment variables when deploying
+	Secrets  map[string]string `mapstructure:"secrets"`
+	Triggers []Trigger         `mapstructure:"triggers"`
+	//Applied as tags on AWS and Azure, as labels on Google and as annotations on OpenWhisk and OpenFaaS
+	Tags map[string]string `mapstructure:"tags"`
 }
 
 type Provider struct {
 	Name    ProviderName `mapstructure:"name"`
 	Handler string       `mapstructure:"handler"`
-	Regions []string     `mapstructure:"regions"`
+	Regions []Region     `mapstructure:"regions"`
 	Runtime string       `mapstructure:"runtime"`
+	//Override the settings of the function for this provider
+	MemorySize          int32 `mapstructure:"memory"`
+	Timeout             int32 `mapstructure:"timeout"`
+	MaxInstances        int32 `mapstructure:"maxInstances"`
+	MinInstances        int32 `mapstructure:"minInstances"`
+	ReservedConcurrency int32 `mapstructure:"reservedConcurrency"`
+	//Override the environment variables and secrets of the function for this provider
+	Environment map[string]string `mapstructure:"environment"`
+	Secrets     map[string]string `mapstructure:"secrets"`
+	//Replace the triggers of the function for this provider
+	Triggers []Trigger `mapstructure:"triggers"`
+	//Merged into the tags of the function, where the provider takes precedence
+	Tags map[string]string `mapstructure:"tags"`
+	//Network settings are specific to a provider, so they can only be set here
+	Network Network `mapstructure:"network"`
+}
+
+//Region of a provider, which can be given as name only or override the settings of the provider
+type Region struct {
+	Name                string `mapstructure:"name"`
+	MemorySize          int32  `mapstructure:"memory"`
+	Timeout             int32  `mapstructure:"timeout"`
+	Runtime             string `mapstructure:"runtime"`
+	MaxInstances        int32  `mapstructure:"maxInstances"`
+	MinInstances        int32  `mapstructure:"minInstances"`
+	ReservedConcurrency int32  `mapstructure:"reservedConcurrency"`
+}
+
+//Decode hook that allows regions to be given as plain region names
+func RegionDecodeHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() == reflect.String && to == reflect.TypeOf(Region{}) {
+		return Region{Name: data.(string)}, nil
+	}
+	return data, nil
+}
+
+//Stage of a deployment, e.g. dev or prod, that replaces the function level settings of all functions
+type Stage struct {
+	Name string `mapstructure:"name"`
+	//Appended to the names of all functions, so the stages of a function can be deployed side by side
+	Suffix              string `mapstructure:"suffix"`
+	MemorySize          int32  `mapstructure:"memory"`
+	Timeout             int32  `mapstructure:"timeout"`
+	MaxInstances        int32  `mapstructure:"maxInstances"`
+	MinInstances        int32  `mapstructure:"minInstances"`
+	ReservedConcurrency int32  `mapstructure:"reservedConcurrency"`
+	//Replace the regions of the given providers
+	Regions map[ProviderName][]Region `mapstructure:"regions"`
+	//Merged into the environment variables and secrets of all functions
+	Environment map[string]string `mapstructure:"environment"`
+	Secrets     map[string]string `mapstructure:"secrets"`
+	//Credentials files used for the given providers instead of the default ones
+	Credentials map[ProviderName]string `mapstructure:"credentials"`
+}
+
+//Returns the function with the settings of the stage applied
+func (s Stage) Apply(dto DeploymentDto) DeploymentDto {
+	dto.Name += s.Suffix
+	dto.MemorySize = Override(dto.MemorySize, s.MemorySize)
+	dto.Timeout = Override(dto.Timeout, s.Timeout)
+	dto.MaxInstances = Override(dto.MaxInstances, s.MaxInstances)
+	dto.MinInstances = Override(dto.MinInstances, s.MinInstances)
+	dto.ReservedConcurrency = Override(dto.ReservedConcurrency, s.ReservedConcurrency)
+	dto.Environment = mergeMaps(dto.Environment, s.Environment)
+	dto.Secrets = mergeMaps(dto.Secrets, s.Secrets)
+
+	providers := make([]Provider, len(dto.Providers))
+	for i, provider := range dto.Providers {
+		if regions, ok := s.Regions[provider.Name]; ok {
+			provider.Regions = regions
+		}
+		providers[i] = provider
+	}
+	dto.Providers = providers
+	return dto
+}
+
+func mergeMaps(maps ...map[string]string) map[string]string {
+	result := make(map[string]string)
+	for _, m := range maps {
+		for key, value := range m {
+			result[key] = value
+		}
+	}
+	return result
 }
 
 type ProviderName string
@@ -34,6 +204,11 @@ type ProviderName string
 const (
 	ProviderAWS    ProviderName = "AWS"
 	ProviderGoogle ProviderName = "Google"
+	ProviderAzure  ProviderName = "Azure"
+	//Self-hosted Apache OpenWhisk, whose regions are the clusters configured in its credentials file
+	ProviderOpenWhisk ProviderName = "OpenWhisk"
+	//Self-hosted OpenFaaS, whose regions are the gateways configured in its credentials file
+	ProviderOpenFaaS ProviderName = "OpenFaaS"
 )
 
 func CheckDeployment(de Deployment) error {
@@ -51,7 +226,7 @@ func CheckDeployment(de Deployment) error {
 	if len(de.Runtime) == 0 {
 		unparsedKeys = append(unparsedKeys, "Runtime")
 	}
-	if len(de.Provider) == 0 || !(string(de.Provider) == string(ProviderAWS) || string(de.Provider) == string(ProviderGoogle)) {
+	if _, ok := GetProvider(de.Provider); !ok {
 		unparsedKeys = append(unparsedKeys, "Provider")
 	}
 	if len(de.HandlerFile) == 0 {
@@ -70,3 +245,22 @@ func CheckDeployment(de Deployment) error {
 		return &DeploymentParseError{UnparsedKeys: unparsedKeys}
 	}
 }
+
+//Returns the version to roll back to, which is either the requested version or the one deployed before the current version
+func RollbackVersion(versions []string, current string, requested string) (string, error) {
+	sort.Strings(versions)
+
+	if requested != "" {
+		if !Contains(versions, requested) {
+			return "", fmt.Errorf("version %v does not exist, available versions are %v", requested, versions)
+		}
+		return requested, nil
+	}
+
+	for i := len(versions) - 1; i > 0; i-- {
+		if versions[i] == current {
+			return versions[i-1], nil
+		}
+	}
+	return "", fmt.Errorf("no version before the current version %v, available versions are %v", current, versions)
+}
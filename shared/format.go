@@ -0,0 +1,236 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/hashicorp/hcl/hcl/ast"
+	hclParser "github.com/hashicorp/hcl/hcl/parser"
+	hclToken "github.com/hashicorp/hcl/hcl/token"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//Deployment file name that reads the deployment document from stdin
+const StdinFile = "-"
+
+type FileFormat string
+
+const (
+	FormatYAML FileFormat = "yaml"
+	FormatJSON FileFormat = "json"
+	FormatTOML FileFormat = "toml"
+	FormatHCL  FileFormat = "hcl"
+)
+
+//Extensions of the supported formats, JSON is parsed as YAML which keeps its line numbers
+var formatExtensions = map[string]FileFormat{
+	".yaml": FormatYAML,
+	".yml":  FormatYAML,
+	".json": FormatJSON,
+	".toml": FormatTOML,
+	".hcl":  FormatHCL,
+}
+
+//Sections whose HCL blocks are collected into lists, even if there is only one block
+var hclListKeys = []string{"functions", "providers", "stages", "triggers", "regions"}
+
+var stdinOnce sync.Once
+var stdinContent []byte
+var stdinErr error
+
+//Returns the path of the deployment file, paths without extension that do not exist are tried with every supported extension
+func ResolveDeploymentFile(file string) string {
+	if file == StdinFile || filepath.Ext(file) != "" {
+		return file
+	}
+	if _, err := os.Stat(file); err == nil {
+		return file
+	}
+	for _, extension := range []string{".yaml", ".yml", ".json", ".toml", ".hcl"} {
+		if _, err := os.Stat(file + extension); err == nil {
+			return file + extension
+		}
+	}
+	return fmt.Sprintf("%v.%v", file, DefaultFileExtension)
+}
+
+//Reads a deployment file or stdin, stdin is only read once so the document can be loaded by several commands
+func readDeploymentSource(file string) ([]byte, error) {
+	if file != StdinFile {
+		return os.ReadFile(file)
+	}
+	stdinOnce.Do(func() {
+		stdinContent, stdinErr = io.ReadAll(os.Stdin)
+	})
+	return stdinContent, stdinErr
+}
+
+//Parses a deployment file into a YAML document, so all formats share the same includes, references and validation.
+//The format is detected by the extension, documents without a known extension (like stdin) are tried as YAML, TOML and HCL
+func parseDeploymentFile(file string, content []byte) (*yaml.Node, error) {
+	format, ok := formatExtensions[strings.ToLower(filepath.Ext(file))]
+	if ok {
+		return parseFormat(format, content)
+	}
+	if file != StdinFile {
+		return nil, fmt.Errorf("unsupported extension %v, supported extensions are .yaml, .yml, .json, .toml and .hcl", filepath.Ext(file))
+	}
+
+	var errors []string
+	for _, format := range []FileFormat{FormatYAML, FormatTOML, FormatHCL} {
+		document, err := parseFormat(format, content)
+		if err == nil && (len(document.Content) == 0 || document.Content[0].Kind == yaml.MappingNode) {
+			return document, nil
+		}
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%v: %v", format, err))
+		}
+	}
+	return nil, fmt.Errorf("unable to detect format, %v", strings.Join(errors, ", "))
+}
+
+func parseFormat(format FileFormat, content []byte) (*yaml.Node, error) {
+	document := &yaml.Node{Kind: yaml.DocumentNode}
+	switch format {
+	case FormatTOML:
+		tree, err := toml.LoadBytes(content)
+		if err != nil {
+			return nil, err
+		}
+		document.Content = []*yaml.Node{tomlNode(tree, tree.Position().Line)}
+	case FormatHCL:
+		file, err := hclParser.Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		root, ok := file.Node.(*ast.ObjectList)
+		if !ok {
+			return nil, fmt.Errorf("deployment file must contain an object")
+		}
+		document.Content = []*yaml.Node{hclObject(root, 1, nil)}
+	default:
+		if err := yaml.Unmarshal(content, document); err != nil {
+			return nil, err
+		}
+	}
+	return document, nil
+}
+
+//Converts a TOML value to a YAML node, keys are sorted by their position in the file
+func tomlNode(value interface{}, line int) *yaml.Node {
+	switch v := value.(type) {
+	case *toml.Tree:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line}
+		keys := v.Keys()
+		sort.Slice(keys, func(i, j int) bool {
+			pi, pj := v.GetPosition(keys[i]), v.GetPosition(keys[j])
+			return pi.Line < pj.Line || (pi.Line == pj.Line && pi.Col < pj.Col)
+		})
+		for _, key := range keys {
+			keyLine := v.GetPosition(key).Line
+			node.Content = append(node.Content, scalarNode(key, "!!str", keyLine), tomlNode(v.Get(key), keyLine))
+		}
+		return node
+	case []*toml.Tree:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, tree := range v {
+			node.Content = append(node.Content, tomlNode(tree, tree.Position().Line))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, item := range v {
+			node.Content = append(node.Content, tomlNode(item, line))
+		}
+		return node
+	case string:
+		return scalarNode(v, "!!str", line)
+	case bool:
+		return scalarNode(strconv.FormatBool(v), "!!bool", line)
+	case int64:
+		return scalarNode(strconv.FormatInt(v, 10), "!!int", line)
+	case float64:
+		return scalarNode(strconv.FormatFloat(v, 'f', -1, 64), "!!float", line)
+	}
+	return scalarNode(fmt.Sprint(value), "!!str", line)
+}
+
+//Converts an HCL object to a YAML mapping. Repeated blocks and blocks of list sections are collected into lists,
+//labels of blocks in list sections are used as their name, e.g. functions "hello" { ... }
+func hclObject(list *ast.ObjectList, line int, path []string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line}
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		key := hclKey(item.Keys[0])
+		keyLine := item.Keys[0].Pos().Line
+		value := hclValue(item.Val, append(path, key))
+
+		isList := Contains(hclListKeys, key) && !(key == "regions" && Contains(path, "stages"))
+		if len(item.Keys) > 1 {
+			label := scalarNode(hclKey(item.Keys[1]), "!!str", item.Keys[1].Pos().Line)
+			if isList && value.Kind == yaml.MappingNode {
+				value.Content = append([]*yaml.Node{scalarNode("name", "!!str", label.Line), label}, value.Content...)
+			} else {
+				value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: label.Line, Content: []*yaml.Node{label, value}}
+			}
+		}
+
+		existing := GetValue(node, key)
+		switch {
+		case isList && value.Kind == yaml.MappingNode && existing != nil && existing.Kind == yaml.SequenceNode:
+			existing.Content = append(existing.Content, value)
+		case isList && value.Kind == yaml.MappingNode:
+			node.Content = append(node.Content, scalarNode(key, "!!str", keyLine), &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: keyLine, Content: []*yaml.Node{value}})
+		case existing != nil && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			existing.Content = append(existing.Content, value.Content...)
+		default:
+			node.Content = append(node.Content, scalarNode(key, "!!str", keyLine), value)
+		}
+	}
+	return node
+}
+
+func hclValue(value ast.Node, path []string) *yaml.Node {
+	line := value.Pos().Line
+	switch v := value.(type) {
+	case *ast.ObjectType:
+		return hclObject(v.List, line, path)
+	case *ast.ListType:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, item := range v.List {
+			node.Content = append(node.Content, hclValue(item, path))
+		}
+		return node
+	case *ast.LiteralType:
+		switch v.Token.Type {
+		case hclToken.NUMBER:
+			return scalarNode(v.Token.Text, "!!int", line)
+		case hclToken.FLOAT:
+			return scalarNode(v.Token.Text, "!!float", line)
+		case hclToken.BOOL:
+			return scalarNode(v.Token.Text, "!!bool", line)
+		}
+		return scalarNode(fmt.Sprint(v.Token.Value()), "!!str", line)
+	}
+	return scalarNode("", "!!null", line)
+}
+
+func hclKey(key *ast.ObjectKey) string {
+	if value, ok := key.Token.Value().(string); ok {
+		return value
+	}
+	return key.Token.Text
+}
+
+//Scalars are plain, so strings that only consist of a reference are typed by their resolved value like in YAML
+func scalarNode(value string, tag string, line int) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Line: line}
+}
@@ -0,0 +1,243 @@
+package shared
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+//Lists of these sections are merged by the name of their entries, all other lists are replaced
+var namedLists = []string{"functions", "providers", "stages"}
+
+//Deployment file with all included files and defaults merged into it
+type DeploymentDocument struct {
+	Node *yaml.Node
+	file string
+	//File every node was read from, nodes without an entry belong to the deployment file itself
+	files map[*yaml.Node]string
+}
+
+//Returns the file the node was read from
+func (d *DeploymentDocument) FileOf(node *yaml.Node) string {
+	if file, ok := d.files[node]; ok {
+		return file
+	}
+	return d.file
+}
+
+//Reads the deployment file and merges the files it includes, included files are merged in the order they are listed
+//and the including file is merged last, so later values win. Finally the defaults are applied to every function
+func loadDeploymentDocument(file string) (*DeploymentDocument, []ValidationError) {
+	d := &DeploymentDocument{file: file, files: make(map[*yaml.Node]string)}
+	l := loader{document: d}
+
+	root := l.load(file, nil)
+	if root == nil || len(l.errors) > 0 {
+		return nil, l.errors
+	}
+	if root.Kind == yaml.DocumentNode { //The deployment file is empty
+		d.Node = root
+		return d, nil
+	}
+	d.Node = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	if root.Kind == yaml.MappingNode {
+		l.applyDefaults(root)
+	}
+	return d, l.errors
+}
+
+type loader struct {
+	document *DeploymentDocument
+	errors   []ValidationError
+}
+
+func (l *loader) addError(file string, node *yaml.Node, format string, args ...interface{}) {
+	err := ValidationError{File: file, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		err.Line = node.Line
+	}
+	l.errors = append(l.errors, err)
+}
+
+//Returns the root node of the file with all of its includes merged, the stack contains the files currently being included
+func (l *loader) load(file string, stack []string) *yaml.Node {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		path = file
+	}
+	if Contains(stack, path) {
+		l.addError(file, nil, "include cycle %v", strings.Join(append(stack, path), " -> "))
+		return nil
+	}
+	stack = append(stack, path)
+
+	content, err := readDeploymentSource(file)
+	if err != nil {
+		l.addError(file, nil, "unable to read deployment file, Error: %v", err)
+		return nil
+	}
+	document, err := parseDeploymentFile(file, content)
+	if err != nil {
+		l.addError(file, nil, "unable to parse deployment file, Error: %v", err)
+		return nil
+	}
+	if len(document.Content) == 0 {
+		return document
+	}
+
+	root := document.Content[0]
+	if file != l.document.file {
+		l.recordFile(root, file)
+	}
+	include := GetValue(root, "include")
+	if root.Kind != yaml.MappingNode || include == nil {
+		return root
+	}
+	if include.Kind != yaml.SequenceNode {
+		l.addError(file, include, "include must be a list of deployment files")
+		return root
+	}
+
+	var merged *yaml.Node
+	for _, includeNode := range include.Content {
+		if includeNode.Kind != yaml.ScalarNode || includeNode.Value == "" {
+			l.addError(file, includeNode, "included file must be a non empty string")
+			continue
+		}
+		includePath := includeNode.Value
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(file), includePath)
+		}
+
+		included := l.load(includePath, stack)
+		if included == nil || included.Kind != yaml.MappingNode {
+			continue
+		}
+		merged = l.merge(merged, included, "")
+	}
+	removeKey(root, "include")
+	return l.merge(merged, root, "")
+}
+
+func (l *loader) recordFile(node *yaml.Node, file string) {
+	l.document.files[node] = file
+	for _, child := range node.Content {
+		l.recordFile(child, file)
+	}
+}
+
+//Merges the override into the base node, mappings are merged key by key and lists of named entries by their name
+func (l *loader) merge(base *yaml.Node, override *yaml.Node, key string) *yaml.Node {
+	if base == nil {
+		return override
+	}
+	if base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode {
+		result := l.derive(override)
+		result.Content = append([]*yaml.Node{}, base.Content...)
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			overrideKey, overrideValue := override.Content[i], override.Content[i+1]
+			if index := keyIndex(result, overrideKey.Value); index >= 0 {
+				result.Content[index+1] = l.merge(result.Content[index+1], overrideValue, overrideKey.Value)
+			} else {
+				result.Content = append(result.Content, overrideKey, overrideValue)
+			}
+		}
+		return result
+	}
+	if base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode && Contains(namedLists, key) {
+		result := l.derive(override)
+		result.Content = append([]*yaml.Node{}, base.Content...)
+		for _, entry := range override.Content {
+			if index := namedEntryIndex(result, entry); index >= 0 {
+				result.Content[index] = l.merge(result.Content[index], entry, "")
+			} else {
+				result.Content = append(result.Content, entry)
+			}
+		}
+		return result
+	}
+	return override
+}
+
+//Returns a copy of the node without children, that belongs to the same file
+func (l *loader) derive(node *yaml.Node) *yaml.Node {
+	result := *node
+	result.Content = nil
+	if file, ok := l.document.files[node]; ok {
+		l.document.files[&result] = file
+	}
+	return &result
+}
+
+func (l *loader) copy(node *yaml.Node) *yaml.Node {
+	result := l.derive(node)
+	for _, child := range node.Content {
+		result.Content = append(result.Content, l.copy(child))
+	}
+	return result
+}
+
+//Merges every function onto the defaults, so functions only have to state what differs from them
+func (l *loader) applyDefaults(root *yaml.Node) {
+	defaults := GetValue(root, "defaults")
+	if defaults == nil {
+		return
+	}
+	removeKey(root, "defaults")
+	if defaults.Kind != yaml.MappingNode {
+		l.addError(l.document.FileOf(defaults), defaults, "defaults must be a mapping")
+		return
+	}
+	for i := len(defaults.Content) - 2; i >= 0; i -= 2 {
+		if key := defaults.Content[i]; key.Value == "name" || !isFunctionKey(key.Value) {
+			l.addError(l.document.FileOf(key), key, "unknown key %v in defaults", key.Value)
+			removeKey(defaults, key.Value)
+		}
+	}
+
+	functions := GetValue(root, "functions")
+	if functions == nil || functions.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, function := range functions.Content {
+		if function.Kind == yaml.MappingNode {
+			//Every function gets its own copy, as references are resolved in place
+			functions.Content[i] = l.merge(l.copy(defaults), function, "")
+		}
+	}
+}
+
+func isFunctionKey(key string) bool {
+	_, ok := functionKeys[key]
+	return ok
+}
+
+func keyIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+//Returns the index of the entry with the same name, or -1 if there is none or the entry has no name
+func namedEntryIndex(list *yaml.Node, entry *yaml.Node) int {
+	name := GetValue(entry, "name")
+	if entry.Kind != yaml.MappingNode || name == nil {
+		return -1
+	}
+	for i, existing := range list.Content {
+		if existingName := GetValue(existing, "name"); existing.Kind == yaml.MappingNode && existingName != nil && existingName.Value == name.Value {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeKey(node *yaml.Node, key string) {
+	if index := keyIndex(node, key); index >= 0 {
+		node.Content = append(node.Content[:index], node.Content[index+2:]...)
+	}
+}
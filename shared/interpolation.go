@@ -0,0 +1,136 @@
+package shared
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//References of the form ${<SOURCE>:<NAME>}, $${ is kept as a literal ${
+var referencePattern = regexp.MustCompile(`\$?\$\{([^:}]*):([^}]*)\}`)
+
+const (
+	referenceEnv  = "env"
+	referenceVar  = "var"
+	referenceFile = "file"
+)
+
+var referenceSources = []string{referenceEnv, referenceVar, referenceFile}
+
+//Reads the deployment file with its includes and resolves the references of all values, returns every reference that can not be resolved.
+//Variables override the ones defined in the variables section of the deployment file
+func ReadDeploymentDocument(file string, variables map[string]string) (*DeploymentDocument, []ValidationError) {
+	document, errors := loadDeploymentDocument(file)
+	if document == nil || len(document.Node.Content) == 0 || document.Node.Content[0].Kind != yaml.MappingNode {
+		return document, errors
+	}
+
+	r := resolver{document: document, variables: make(map[string]string), errors: errors}
+	root := document.Node.Content[0]
+	variablesNode := GetValue(root, "variables")
+	if variablesNode != nil && variablesNode.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(variablesNode.Content); i += 2 {
+			//Variables can only reference the environment and files
+			r.resolveNode(variablesNode.Content[i+1], referenceEnv, referenceFile)
+			r.variables[variablesNode.Content[i].Value] = variablesNode.Content[i+1].Value
+		}
+	}
+	for name, value := range variables {
+		r.variables[name] = value
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i+1] != variablesNode {
+			r.resolveNode(root.Content[i+1], referenceEnv, referenceVar, referenceFile)
+		}
+	}
+	return document, r.errors
+}
+
+//Returns the deployment file with all references resolved, so it can be read by viper
+func InterpolateDeploymentFile(file string, variables map[string]string) ([]byte, []ValidationError) {
+	document, errors := ReadDeploymentDocument(file, variables)
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	content, err := yaml.Marshal(document.Node)
+	if err != nil {
+		return nil, []ValidationError{{File: file, Message: fmt.Sprintf("unable to write interpolated deployment file, Error: %v", err)}}
+	}
+	return content, nil
+}
+
+type resolver struct {
+	document  *DeploymentDocument
+	variables map[string]string
+	errors    []ValidationError
+}
+
+func (r *resolver) resolveNode(node *yaml.Node, sources ...string) {
+	if node.Kind != yaml.ScalarNode {
+		for _, child := range node.Content {
+			r.resolveNode(child, sources...)
+		}
+		return
+	}
+	if !strings.Contains(node.Value, "${") {
+		return
+	}
+
+	node.Value = referencePattern.ReplaceAllStringFunc(node.Value, func(reference string) string {
+		if strings.HasPrefix(reference, "$$") {
+			return reference[1:]
+		}
+		match := referencePattern.FindStringSubmatch(reference)
+		value, err := r.lookup(node, match[1], match[2], sources)
+		if err != nil {
+			r.errors = append(r.errors, ValidationError{File: r.document.FileOf(node), Line: node.Line, Message: fmt.Sprintf("unresolved reference %v, %v", reference, err)})
+			return reference
+		}
+		return value
+	})
+	//Plain values are typed by their resolved value, so ${var:memory} can be used as a number
+	if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		node.Tag = ""
+		node.Tag = node.ShortTag()
+	}
+}
+
+func (r *resolver) lookup(node *yaml.Node, source string, name string, sources []string) (string, error) {
+	if !Contains(referenceSources, source) {
+		return "", fmt.Errorf("unknown source %v, valid sources are %v", source, strings.Join(referenceSources, "|"))
+	}
+	if !Contains(sources, source) {
+		return "", fmt.Errorf("only references to %v can be used here", strings.Join(sources, "|"))
+	}
+
+	switch source {
+	case referenceEnv:
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %v is not set", name)
+		}
+		return value, nil
+	case referenceVar:
+		value, ok := r.variables[name]
+		if !ok {
+			return "", fmt.Errorf("variable %v is neither defined in the variables section nor given with --var", name)
+		}
+		return value, nil
+	case referenceFile:
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(r.document.FileOf(node)), path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read file, Error: %v", err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return "", nil
+}
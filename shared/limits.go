@@ -0,0 +1,48 @@
+package shared
+
+import (
+	"regexp"
+	"strings"
+)
+
+//Limits and supported values of a FaaS provider, used to validate deployment files
+type ProviderLimits struct {
+	MinMemory int32
+	MaxMemory int32
+	//If set, only these memory sizes are allowed
+	MemorySizes []int32
+	MinTimeout  int32
+	MaxTimeout  int32
+	//If empty, every runtime is allowed, e.g. the images of OpenFaaS functions
+	Runtimes []string
+	//If empty, every region is allowed, as the regions of self-hosted providers are defined by their credentials
+	Regions []string
+	//Environment variables set by the provider that can not be used, entries ending with _ reserve all names with that prefix
+	ReservedEnvironment []string
+	//If empty, every trigger type is allowed
+	TriggerTypes []TriggerType
+	//Keys of the network section supported by the provider
+	NetworkKeys []string
+	//Setting that minInstances can not exceed, either maxInstances, which defaults to DefaultMaxFunctionInstances, or reservedConcurrency.
+	//If empty, minInstances is not checked against other settings
+	MinInstancesLimit string
+}
+
+//Handler formats (<HANDLER_FILE>.<HANDLER_METHOD>) per runtime family, runtimes without an entry only need both parts
+var handlerPatterns = map[string]*regexp.Regexp{
+	"python": regexp.MustCompile(`^[A-Za-z_]\w*\.[A-Za-z_]\w*$`),
+	"java":   regexp.MustCompile(`^[A-Za-z_$][\w$]*\.[A-Za-z_$][\w$]*$`),
+	"nodejs": regexp.MustCompile(`^[\w-]+\.[A-Za-z_$][\w$]*$`),
+	"go":     regexp.MustCompile(`^\w+\.[A-Z]\w*$`),
+}
+
+var defaultHandlerPattern = regexp.MustCompile(`^[^.]+\.[^.]+$`)
+
+func getHandlerPattern(runtime string) *regexp.Regexp {
+	for family, pattern := range handlerPatterns {
+		if strings.HasPrefix(runtime, family) {
+			return pattern
+		}
+	}
+	return defaultHandlerPattern
+}
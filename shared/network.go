@@ -0,0 +1,31 @@
+package shared
+
+//Egress settings of Google functions with a VPC connector
+const (
+	EgressPrivateRanges = "private-ranges-only"
+	EgressAll           = "all"
+)
+
+var EgressSettings = []string{EgressPrivateRanges, EgressAll}
+
+//Ingress settings of Google functions
+const (
+	IngressAll             = "all"
+	IngressInternalOnly    = "internal-only"
+	IngressInternalAndGclb = "internal-and-gclb"
+)
+
+var IngressSettings = []string{IngressAll, IngressInternalOnly, IngressInternalAndGclb}
+
+//Network configuration of a function, subnets and security groups are used by AWS, the other settings by Google
+type Network struct {
+	//IDs of the subnets and security groups of the VPC the function is connected to
+	Subnets        []string `mapstructure:"subnets"`
+	SecurityGroups []string `mapstructure:"securityGroups"`
+	//Name of the Serverless VPC Access connector in the region of the function, or its full resource name
+	VpcConnector string `mapstructure:"vpcConnector"`
+	//Traffic routed through the VPC connector, defaults to private-ranges-only
+	Egress string `mapstructure:"egress"`
+	//Sources the function accepts requests from, defaults to all
+	Ingress string `mapstructure:"ingress"`
+}
@@ -0,0 +1,146 @@
+//Helpers for testing providers against local fakes of their APIs
+package providertest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"godeploy/shared"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+//Name of the function deployed by the tests
+const FunctionName = "hello"
+
+//Starts a server for the fake API, which is closed when the test finishes.
+//Requests are handled one at a time, so fakes can keep their state in plain maps
+func NewServer(t *testing.T, handler http.Handler) *httptest.Server {
+	server := httptest.NewServer(serialize(handler))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func NewTLSServer(t *testing.T, handler http.Handler) *httptest.Server {
+	server := httptest.NewTLSServer(serialize(handler))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func serialize(handler http.Handler) http.Handler {
+	var lock sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+//Sends all requests of clients using the default transport to the server regardless of their host, for APIs whose hosts can not be configured.
+//The certificate of the server is not verified, as it is not issued for the hosts of the requests
+func RouteDefaultTransport(t *testing.T, server *httptest.Server) {
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+}
+
+func WriteJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+//Returns a deployment of the test function from a local archive, whose content is "archive <VERSION>"
+func NewDeployment(t *testing.T, provider shared.ProviderName, region string, version string, environment map[string]string) shared.Deployment {
+	archive := filepath.Join(t.TempDir(), FunctionName+".zip")
+	if err := os.WriteFile(archive, []byte("archive "+version), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return shared.Deployment{
+		Archive:     archive,
+		Name:        FunctionName,
+		MemorySize:  256,
+		Timeout:     30,
+		Provider:    provider,
+		Region:      region,
+		Version:     version,
+		Environment: environment,
+		Tags:        map[string]string{shared.ManagedByTag: shared.ManagedByValue},
+	}
+}
+
+//Deploys, reads and deletes the test function through the provider, which is backed by a fake of its API
+type Lifecycle struct {
+	Client shared.ProviderPlugin
+	//Config of the region the function is deployed to
+	Config shared.Config
+	//Returns the deployment of the given version
+	Deployment func(version string) shared.Deployment
+	//Checks the state of the fake after a version was deployed, optional
+	AfterDeploy func(t *testing.T, d shared.Deployment, result shared.DeploymentResult)
+	//Checks the state of the fake after the function was deleted, optional
+	AfterDelete func(t *testing.T)
+}
+
+//Deploys two versions with CreateFunction, the second one has to update the function created by the first one.
+//The deployed function has to match the desired function of the deployment, afterwards it is deleted twice
+func (l Lifecycle) Run(t *testing.T) {
+	if f, err := l.Client.GetFunction(l.Config, FunctionName); f != nil || err != nil {
+		t.Fatalf("GetFunction before the first deployment = %+v, %v, want nil without error", f, err)
+	}
+
+	var d shared.Deployment
+	for i, version := range []string{"v1", "v2"} {
+		d = l.Deployment(version)
+		result := l.Client.CreateFunction(l.Config, d)
+		if result.Err != nil {
+			t.Fatalf("CreateFunction of %v failed: %v", version, result.Err)
+		}
+		wantAction := shared.ActionCreate
+		if i > 0 {
+			wantAction = shared.ActionUpdate
+		}
+		if result.Action != wantAction || result.Version != version || result.Function == "" {
+			t.Errorf("CreateFunction of %v = %v of version %v as %q, want %v with a function identifier", version, result.Action, result.Version, result.Function, wantAction)
+		}
+		if l.AfterDeploy != nil {
+			l.AfterDeploy(t, d, result)
+		}
+	}
+
+	f, err := l.Client.GetFunction(l.Config, FunctionName)
+	if f == nil || err != nil {
+		t.Fatalf("GetFunction after deploying = %+v, %v, want the function", f, err)
+	}
+	if f.Name != FunctionName || f.Region != l.Config.Region || f.Provider != d.Provider {
+		t.Errorf("GetFunction = %v of %v in %v, want %v of %v in %v", f.Name, f.Provider, f.Region, FunctionName, d.Provider, l.Config.Region)
+	}
+	if changes := shared.CompareFunctions(*f, l.Client.DesiredFunction(d)); len(changes) > 0 {
+		t.Errorf("deployed function differs from the desired function: %+v", changes)
+	}
+
+	result := l.Client.DeleteFunction(l.Config, d, true)
+	if result.Err != nil || result.Action != shared.ActionDelete {
+		t.Fatalf("DeleteFunction = %v, %v, want %v without error", result.Action, result.Err, shared.ActionDelete)
+	}
+	if l.AfterDelete != nil {
+		l.AfterDelete(t)
+	}
+	if f, err := l.Client.GetFunction(l.Config, FunctionName); f != nil || err != nil {
+		t.Errorf("GetFunction after DeleteFunction = %+v, %v, want nil without error", f, err)
+	}
+	//Deleting a function that does not exist is not an error, so remove can be repeated
+	if result := l.Client.DeleteFunction(l.Config, d, true); result.Err != nil {
+		t.Errorf("DeleteFunction of deleted function failed: %v", result.Err)
+	}
+}
@@ -0,0 +1,85 @@
+package shared
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//FaaS provider that can be registered, so commands, validation and credential loading work without knowing the provider
+type ProviderPlugin interface {
+	Client
+	//Name used in the providers section of deployment files
+	Name() ProviderName
+	//Limits and supported values used to validate deployment files
+	Limits() ProviderLimits
+	//Checks the rules of the provider that are not covered by its limits for a setting of a function or provider section, e.g. the tags or triggers.
+	//Called after the provider neutral checks of the setting, which already report malformed entries, problems are reported with the node they are found in
+	Validate(section string, key string, node *yaml.Node, report ValidationReporter)
+	//Region used if no region is given explicitly
+	DefaultRegion() string
+	//Runtime used by init if no runtime is given explicitly
+	DefaultRuntime() string
+	//Name of the credentials file without extension, which can be replaced by a stage
+	CredentialsFile() string
+	//Content of the placeholder credentials file created by init
+	CredentialsTemplate() string
+	//Reads the credentials of the provider from the given credentials file, which has already been merged into the configuration
+	LoadCredentials(file string, credentials *CredentialsHolder) error
+	//Whether the archive is stored at the provider, so its credentials are needed to copy it
+	IsArchiveURI(archive string) bool
+	//Uploads the archive of the deployment and returns the deployment referencing the uploaded archive
+	UploadArchive(cfg Config, d Deployment) (Deployment, error)
+	//Deletes the function and its triggers, and the uploaded archives if requested, failures are reported in the result like deployments
+	DeleteFunction(cfg Config, d Deployment, removeArchive bool) DeploymentResult
+	//Restores the given version of the function, or the version deployed before the current one if no version is given.
+	//The result holds the restored version
+	RollbackFunction(cfg Config, d Deployment, version string) DeploymentResult
+}
+
+var providerRegistry = make(map[ProviderName]ProviderPlugin)
+var providerRegistryLock sync.RWMutex
+
+//Registers a provider under its name, usually from the init function of its package
+func RegisterProvider(provider ProviderPlugin) {
+	providerRegistryLock.Lock()
+	defer providerRegistryLock.Unlock()
+	if _, ok := providerRegistry[provider.Name()]; ok {
+		panic(fmt.Sprintf("provider %v is already registered", provider.Name()))
+	}
+	providerRegistry[provider.Name()] = provider
+}
+
+func GetProvider(name ProviderName) (ProviderPlugin, bool) {
+	providerRegistryLock.RLock()
+	defer providerRegistryLock.RUnlock()
+	provider, ok := providerRegistry[name]
+	return provider, ok
+}
+
+//Returns the names of all registered providers in alphabetical order
+func ProviderNames() []ProviderName {
+	providerRegistryLock.RLock()
+	defer providerRegistryLock.RUnlock()
+	var names []ProviderName
+	for name := range providerRegistry {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+//Returns the registered providers in the format used by error messages, e.g. AWS|Google
+func ValidProviders() string {
+	return strings.Join(Map(ProviderNames(), func(name ProviderName) string { return string(name) }), "|")
+}
+
+func getProviderLimits(name ProviderName) (ProviderLimits, bool) {
+	provider, ok := GetProvider(name)
+	if !ok {
+		return ProviderLimits{}, false
+	}
+	return provider.Limits(), true
+}
@@ -0,0 +1,46 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+//Actions performed on a function in a single provider region
+type DeploymentAction string
+
+const (
+	ActionCreate   DeploymentAction = "create"
+	ActionUpdate   DeploymentAction = "update"
+	ActionDelete   DeploymentAction = "delete"
+	ActionRollback DeploymentAction = "rollback"
+)
+
+//Outcome of deploying, deleting or rolling back a function in a single provider region, failed targets do not stop the others
+type DeploymentResult struct {
+	Name     string
+	Provider ProviderName
+	Region   string
+	Version  string
+	Action   DeploymentAction
+	Duration time.Duration
+	//Provider specific identifier of the deployed function, empty if the function was not deployed
+	Function string
+	//Error that stopped the target, nil if it succeeded
+	Err error
+}
+
+func NewDeploymentResult(d Deployment) DeploymentResult {
+	return DeploymentResult{Name: d.Name, Provider: d.Provider, Region: d.Region, Version: d.Version}
+}
+
+//Logs the failure of the target and returns the result with the error and the elapsed time set
+func (r DeploymentResult) Fail(err error, start time.Time) DeploymentResult {
+	r.Err = err
+	r.Duration = time.Since(start)
+	action := "deploy"
+	if r.Action != "" {
+		action = string(r.Action)
+	}
+	Log(r.Provider, fmt.Sprintf("Unable to %v function %v in region %v, Error: %v", action, r.Name, r.Region, err))
+	return r
+}
@@ -0,0 +1,14 @@
+package shared
+
+//Tag added to every function and archive, so resources created by GoDeploy can be found
+const ManagedByTag = "managed-by"
+const ManagedByValue = "godeploy"
+
+//Returns the tags with the managed-by tag added
+func WithManagedBy(tags map[string]string) map[string]string {
+	result := map[string]string{ManagedByTag: ManagedByValue}
+	for key, value := range tags {
+		result[key] = value
+	}
+	return result
+}
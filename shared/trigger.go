@@ -0,0 +1,107 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type TriggerType string
+
+const (
+	TriggerHTTP     TriggerType = "http"
+	TriggerSchedule TriggerType = "schedule"
+	TriggerQueue    TriggerType = "queue"
+	TriggerTopic    TriggerType = "topic"
+	TriggerBucket   TriggerType = "bucket"
+)
+
+var TriggerTypes = []TriggerType{TriggerHTTP, TriggerSchedule, TriggerQueue, TriggerTopic, TriggerBucket}
+
+//Object events of bucket triggers
+const (
+	BucketEventCreated = "created"
+	BucketEventDeleted = "deleted"
+)
+
+//Provider neutral event source of a function, which is mapped to the services of every provider
+type Trigger struct {
+	Type TriggerType `mapstructure:"type"`
+	//Allows unauthenticated requests to http triggers
+	Public bool `mapstructure:"public"`
+	//Cron expression with five fields (minute hour day-of-month month day-of-week) evaluated in UTC
+	Schedule string `mapstructure:"schedule"`
+	//Names of the queue, topic or bucket the function is triggered by, they are not created by GoDeploy
+	Queue  string `mapstructure:"queue"`
+	Topic  string `mapstructure:"topic"`
+	Bucket string `mapstructure:"bucket"`
+	//Object events of bucket triggers, defaults to created
+	Events []string `mapstructure:"events"`
+}
+
+//Returns the name of the resource the trigger is attached to, empty for http triggers
+func (t Trigger) Resource() string {
+	switch t.Type {
+	case TriggerSchedule:
+		return t.Schedule
+	case TriggerQueue:
+		return t.Queue
+	case TriggerTopic:
+		return t.Topic
+	case TriggerBucket:
+		return t.Bucket
+	}
+	return ""
+}
+
+//Returns the events of a bucket trigger, using the default if none are given
+func (t Trigger) BucketEvents() []string {
+	if len(t.Events) == 0 {
+		return []string{BucketEventCreated}
+	}
+	return t.Events
+}
+
+//Returns the first 8 hex characters of the SHA-256 hash of the values, used to derive short and stable resource names
+func ShortHash(values ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "/")))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+//Checks that the schedule is a cron expression with five fields
+func CheckSchedule(schedule string) error {
+	if fields := strings.Fields(schedule); len(fields) != 5 {
+		return fmt.Errorf("schedule %v must be a cron expression with five fields (minute hour day-of-month month day-of-week)", schedule)
+	}
+	return nil
+}
+
+var dayNumberPattern = regexp.MustCompile(`/?\d+`)
+
+//Converts a cron expression with five fields to the six field format of AWS, where day-of-week starts with 1 for Sunday
+//and either day-of-month or day-of-week has to be ?
+func AWSCronExpression(schedule string) string {
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return schedule
+	}
+
+	dayOfWeek := dayNumberPattern.ReplaceAllStringFunc(fields[4], func(match string) string {
+		if strings.HasPrefix(match, "/") { //Steps like */2 are not day numbers
+			return match
+		}
+		day, _ := strconv.Atoi(match)
+		return strconv.Itoa(day%7 + 1)
+	})
+
+	dayOfMonth := fields[2]
+	if dayOfWeek == "*" {
+		dayOfWeek = "?"
+	} else if dayOfMonth == "*" {
+		dayOfMonth = "?"
+	}
+	return fmt.Sprintf("cron(%v %v %v %v %v *)", fields[0], fields[1], dayOfMonth, fields[3], dayOfWeek)
+}
@@ -1,7 +1,9 @@
 package shared
 
 import (
+	"encoding/base64"
 	"fmt"
+	"hash"
 	"io/ioutil"
 	"log"
 	"os"
@@ -33,6 +35,17 @@ func Any[T any, A ~[]T](slice A, predicate func(T) bool) bool {
 	return false
 }
 
+//Returns the last value that is set, so later values override earlier ones
+func Override[T comparable](values ...T) T {
+	var result, zero T
+	for _, v := range values {
+		if v != zero {
+			result = v
+		}
+	}
+	return result
+}
+
 func Map[T any, R any](slice []T, mapper func(T) R) []R {
 	var res []R
 	for _, v := range slice {
@@ -65,6 +78,11 @@ func IsAWSObjectURI(uri string) bool {
 	return strings.HasPrefix(uri, "s3://")
 }
 
+//Whether the URI is the URL of a blob in an Azure storage account
+func IsAzureObjectURI(uri string) bool {
+	return strings.HasPrefix(uri, "https://") && strings.Contains(uri, ".blob.core.windows.net/")
+}
+
 func ParseStorageObjectURI(uri string) (string, string) {
 	pattern := regexp.MustCompile("([\\w-]+)\\/([\\S-]+)")
 	var captureGroups []string
@@ -91,3 +109,12 @@ func CheckErr(err interface{}, msg interface{}) {
 func Log(provider ProviderName, msg string) {
 	log.Println(fmt.Sprintf("%v:", provider), msg)
 }
+
+//Returns the base64 encoded hash of a local file, or an empty string for storage object URIs
+func FileHash(fileLocation string, h hash.Hash) string {
+	if IsAWSObjectURI(fileLocation) || IsGoogleObjectURI(fileLocation) || IsAzureObjectURI(fileLocation) {
+		return ""
+	}
+	h.Write(ReadFile(fileLocation))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
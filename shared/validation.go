@@ -0,0 +1,831 @@
+package shared
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ValidationError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+//Reports a problem found in a node of the deployment file
+type ValidationReporter func(node *yaml.Node, format string, args ...interface{})
+
+func (m ValidationError) Error() string {
+	if m.Line == 0 {
+		return fmt.Sprintf("%v: %v", m.File, m.Message)
+	}
+	return fmt.Sprintf("%v:%v: %v", m.File, m.Line, m.Message)
+}
+
+//Keys allowed in the different sections of a deployment file, mapped to whether they are required
+var documentKeys = map[string]bool{
+	"functions": true,
+	"variables": false,
+	"stages":    false,
+	//Resolved before the deployment file is validated
+	"include":  false,
+	"defaults": false,
+}
+
+//Either archive or source is required, which is checked separately
+var functionKeys = map[string]bool{
+	"archive":             false,
+	"source":              false,
+	"name":                true,
+	"memory":              false,
+	"timeout":             false,
+	"providers":           true,
+	"maxInstances":        false,
+	"minInstances":        false,
+	"reservedConcurrency": false,
+	"environment":         false,
+	"secrets":             false,
+	"triggers":            false,
+	"tags":                false,
+}
+
+var stageKeys = map[string]bool{
+	"name":                true,
+	"suffix":              false,
+	"memory":              false,
+	"timeout":             false,
+	"maxInstances":        false,
+	"minInstances":        false,
+	"reservedConcurrency": false,
+	"regions":             false,
+	"environment":         false,
+	"secrets":             false,
+	"credentials":         false,
+}
+
+var sourceKeys = map[string]bool{
+	"path":    true,
+	"include": false,
+	"exclude": false,
+}
+
+var providerKeys = map[string]bool{
+	"name":                true,
+	"handler":             true,
+	"regions":             true,
+	"runtime":             false,
+	"memory":              false,
+	"timeout":             false,
+	"maxInstances":        false,
+	"minInstances":        false,
+	"reservedConcurrency": false,
+	"environment":         false,
+	"secrets":             false,
+	"triggers":            false,
+	"tags":                false,
+	"network":             false,
+}
+
+//Only type and the key named after it are allowed in a trigger, besides public for http and events for bucket triggers
+var triggerKeys = map[string]bool{
+	"type":     true,
+	"public":   false,
+	"schedule": false,
+	"queue":    false,
+	"topic":    false,
+	"bucket":   false,
+	"events":   false,
+}
+
+//Subnets and security groups are used by AWS, the other keys by Google, the keys supported by a provider are part of its limits
+var networkKeys = map[string]bool{
+	"subnets":        false,
+	"securityGroups": false,
+	"vpcConnector":   false,
+	"egress":         false,
+	"ingress":        false,
+}
+
+//Regions are either plain region names or mappings that override the settings of their provider
+var regionKeys = map[string]bool{
+	"name":                true,
+	"memory":              false,
+	"timeout":             false,
+	"runtime":             false,
+	"maxInstances":        false,
+	"minInstances":        false,
+	"reservedConcurrency": false,
+}
+
+var environmentVariablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+//Validates the whole deployment file after resolving its references and returns every problem found, without contacting any provider.
+//If a stage is given, it must exist and its settings are applied to the functions
+func ValidateDeploymentFile(file string, variables map[string]string, stage string) []ValidationError {
+	document, errors := ReadDeploymentDocument(file, variables)
+	if document == nil {
+		return errors
+	}
+
+	v := validator{document: document, errors: errors, targets: make(map[string]int), stageName: stage}
+	if len(document.Node.Content) == 0 {
+		v.addError(document.Node, "deployment file is empty")
+		return v.errors
+	}
+	v.validateDocument(document.Node.Content[0])
+
+	//Errors of included files are grouped by file
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		if v.errors[i].File != v.errors[j].File {
+			return v.errors[i].File < v.errors[j].File
+		}
+		return v.errors[i].Line < v.errors[j].Line
+	})
+	return v.errors
+}
+
+type validator struct {
+	document *DeploymentDocument
+	errors   []ValidationError
+	//Line of the first definition of every function name, provider and region combination
+	targets map[string]int
+	//Selected stage and its node, once it is found
+	stageName string
+	stage     *yaml.Node
+}
+
+//Adds an error, errors of settings shared by several regions are only reported once
+func (v *validator) addError(node *yaml.Node, format string, args ...interface{}) {
+	err := ValidationError{File: v.document.FileOf(node), Line: node.Line, Message: fmt.Sprintf(format, args...)}
+	if !Contains(v.errors, err) {
+		v.errors = append(v.errors, err)
+	}
+}
+
+//Settings that can be overridden by providers and regions, nil if not set
+type targetSettings struct {
+	memory              *yaml.Node
+	timeout             *yaml.Node
+	runtime             *yaml.Node
+	maxInstances        *yaml.Node
+	minInstances        *yaml.Node
+	reservedConcurrency *yaml.Node
+}
+
+//Returns the settings overridden by the given function, provider or region node, and checks the values of the node
+func (v *validator) overrideSettings(s targetSettings, node *yaml.Node) targetSettings {
+	override := func(current *yaml.Node, key string) *yaml.Node {
+		if value := GetValue(node, key); value != nil {
+			return value
+		}
+		return current
+	}
+	v.checkNumber(GetValue(node, "memory"), "memory")
+	v.checkNumber(GetValue(node, "timeout"), "timeout")
+	v.checkString(GetValue(node, "runtime"), "runtime")
+	for _, key := range []string{"maxInstances", "minInstances", "reservedConcurrency"} {
+		v.checkNumber(GetValue(node, key), key)
+	}
+
+	return targetSettings{
+		memory:              override(s.memory, "memory"),
+		timeout:             override(s.timeout, "timeout"),
+		runtime:             override(s.runtime, "runtime"),
+		maxInstances:        override(s.maxInstances, "maxInstances"),
+		minInstances:        override(s.minInstances, "minInstances"),
+		reservedConcurrency: override(s.reservedConcurrency, "reservedConcurrency"),
+	}
+}
+
+func (v *validator) validateDocument(node *yaml.Node) {
+	if !v.checkMapping(node, "deployment file", documentKeys) {
+		return
+	}
+
+	if variables := GetValue(node, "variables"); variables != nil {
+		if variables.Kind != yaml.MappingNode {
+			v.addError(variables, "variables must be a mapping")
+		} else {
+			for i := 0; i+1 < len(variables.Content); i += 2 {
+				if variables.Content[i+1].Kind != yaml.ScalarNode {
+					v.addError(variables.Content[i+1], "value of variable %v must be a string", variables.Content[i].Value)
+				}
+			}
+		}
+	}
+
+	functions := GetValue(node, "functions")
+	v.validateStages(node, GetValue(node, "stages"), functions)
+	if functions == nil {
+		return
+	}
+	if functions.Kind != yaml.SequenceNode || len(functions.Content) == 0 {
+		v.addError(functions, "functions must be a non empty list")
+		return
+	}
+	for _, function := range functions.Content {
+		v.validateFunction(function)
+	}
+}
+
+func (v *validator) validateFunction(node *yaml.Node) {
+	if !v.checkMapping(node, "function", functionKeys) {
+		return
+	}
+
+	name := v.checkString(GetValue(node, "name"), "name")
+	v.checkString(GetValue(node, "archive"), "archive")
+	v.validateSource(node)
+	settings := v.overrideSettings(targetSettings{}, node)
+	if v.stage != nil {
+		settings = v.overrideSettings(settings, v.stage)
+	}
+	//Variables of the function are set at every provider it is deployed to
+	var providerNames []ProviderName
+	//Triggers of the function are only used by providers without their own triggers
+	var triggerProviderNames []ProviderName
+	if providers := GetValue(node, "providers"); providers != nil {
+		for _, provider := range providers.Content {
+			if nameNode := GetValue(provider, "name"); nameNode != nil {
+				providerNames = append(providerNames, ProviderName(nameNode.Value))
+				if GetValue(provider, "triggers") == nil {
+					triggerProviderNames = append(triggerProviderNames, ProviderName(nameNode.Value))
+				}
+			}
+		}
+	}
+	v.validateEnvironment(node, providerNames)
+	v.validateTriggers(node, "function", triggerProviderNames)
+	v.validateTags(node, "function", providerNames)
+
+	providers := GetValue(node, "providers")
+	if providers == nil {
+		return
+	}
+	if providers.Kind != yaml.SequenceNode || len(providers.Content) == 0 {
+		v.addError(providers, "providers of function %v must be a non empty list", name)
+		return
+	}
+	for _, provider := range providers.Content {
+		v.validateProvider(provider, name, settings)
+	}
+}
+
+func (v *validator) validateSource(function *yaml.Node) {
+	archive, source := GetValue(function, "archive"), GetValue(function, "source")
+	if archive == nil && source == nil {
+		v.addError(function, "missing key archive or source in function")
+	}
+	if archive != nil && source != nil {
+		v.addError(source, "only one of archive and source can be used")
+	}
+	if source == nil || !v.checkMapping(source, "source", sourceKeys) {
+		return
+	}
+
+	v.checkString(GetValue(source, "path"), "source path")
+	for _, key := range []string{"include", "exclude"} {
+		patterns := GetValue(source, key)
+		if patterns == nil {
+			continue
+		}
+		if patterns.Kind != yaml.SequenceNode {
+			v.addError(patterns, "%v must be a list of patterns", key)
+			continue
+		}
+		for _, pattern := range patterns.Content {
+			v.checkString(pattern, key+" pattern")
+		}
+	}
+}
+
+func (v *validator) validateProvider(node *yaml.Node, functionName string, settings targetSettings) {
+	if !v.checkMapping(node, "provider", providerKeys) {
+		return
+	}
+
+	nameNode := GetValue(node, "name")
+	name := ProviderName(v.checkString(nameNode, "provider name"))
+	limits, ok := getProviderLimits(name)
+	if nameNode != nil && name != "" && !ok {
+		v.addError(nameNode, "unknown provider %v, valid values are %v", name, ValidProviders())
+		return
+	}
+
+	handler := v.checkString(GetValue(node, "handler"), "handler")
+	settings = v.overrideSettings(settings, node)
+	v.validateEnvironment(node, []ProviderName{name})
+	v.validateTriggers(node, "provider", []ProviderName{name})
+	v.validateTags(node, "provider", []ProviderName{name})
+	v.validateNetwork(GetValue(node, "network"), name)
+
+	regions := v.getRegions(node, name)
+	if regions == nil {
+		return
+	}
+	if regions.Kind != yaml.SequenceNode || len(regions.Content) == 0 {
+		v.addError(regions, "regions of provider %v must be a non empty list", name)
+		return
+	}
+	for _, regionNode := range regions.Content {
+		regionSettings := settings
+		if regionNode.Kind == yaml.MappingNode {
+			if !v.checkMapping(regionNode, "region", regionKeys) {
+				continue
+			}
+			regionSettings = v.overrideSettings(settings, regionNode)
+			regionNode = GetValue(regionNode, "name")
+			if regionNode == nil {
+				continue
+			}
+		}
+		region := v.checkString(regionNode, "region")
+		if region == "" {
+			continue
+		}
+		if ok && len(limits.Regions) > 0 && !Contains(limits.Regions, region) {
+			v.addError(regionNode, "unknown region %v for provider %v", region, name)
+		}
+
+		target := fmt.Sprintf("%v/%v/%v", functionName, name, region)
+		if line, exists := v.targets[target]; exists {
+			v.addError(regionNode, "function %v is already deployed to region %v of %v in line %v", functionName, region, name, line)
+		} else {
+			v.targets[target] = regionNode.Line
+		}
+
+		v.validateTarget(regionNode, GetValue(node, "handler"), handler, name, region, regionSettings)
+	}
+}
+
+//Returns the regions the provider is deployed to, the regions the selected stage defines for the provider replace its own
+func (v *validator) getRegions(node *yaml.Node, name ProviderName) *yaml.Node {
+	if v.stage != nil {
+		if stageRegions := GetValue(v.stage, "regions"); stageRegions != nil && stageRegions.Kind == yaml.MappingNode {
+			if regions := GetValue(stageRegions, string(name)); regions != nil {
+				return regions
+			}
+		}
+	}
+	return GetValue(node, "regions")
+}
+
+//Checks the settings a function is deployed with to a single region, after all overrides are applied
+func (v *validator) validateTarget(regionNode *yaml.Node, handlerNode *yaml.Node, handler string, provider ProviderName, region string, settings targetSettings) {
+	if settings.memory == nil {
+		v.addError(regionNode, "missing key memory for region %v of %v, it must be set in the function, stage, provider or region", region, provider)
+	}
+	if settings.runtime == nil {
+		v.addError(regionNode, "missing key runtime for region %v of %v, it must be set in the provider or region", region, provider)
+		return
+	}
+
+	limits, ok := getProviderLimits(provider)
+	runtime := settings.runtime.Value
+	if runtime != "" && ok && len(limits.Runtimes) > 0 && !Contains(limits.Runtimes, runtime) {
+		v.addError(settings.runtime, "runtime %v is not supported by %v, supported runtimes are %v", runtime, provider, limits.Runtimes)
+	}
+	if handler != "" && runtime != "" && !getHandlerPattern(runtime).MatchString(handler) {
+		v.addError(handlerNode, "handler %v does not follow the format <HANDLER_FILE>.<HANDLER_METHOD> for runtime %v", handler, runtime)
+	}
+	if ok {
+		v.checkLimits(settings.memory, settings.timeout, provider, limits)
+	}
+	v.checkInstances(settings, provider)
+}
+
+//Checks that the instances kept warm do not exceed the setting that limits them at the provider, e.g. the maximum instances or the reserved concurrency
+func (v *validator) checkInstances(settings targetSettings, provider ProviderName) {
+	minInstances, err := numberValue(settings.minInstances)
+	limits, ok := getProviderLimits(provider)
+	if err != nil || !ok {
+		return
+	}
+	switch limits.MinInstancesLimit {
+	case "maxInstances":
+		maxInstances := DefaultMaxFunctionInstances
+		if value, err := numberValue(settings.maxInstances); err == nil {
+			maxInstances = value
+		}
+		if minInstances > maxInstances {
+			v.addError(settings.minInstances, "minInstances of %v exceeds maxInstances of %v", minInstances, maxInstances)
+		}
+	case "reservedConcurrency":
+		if reserved, err := numberValue(settings.reservedConcurrency); err == nil && minInstances > reserved {
+			v.addError(settings.minInstances, "minInstances of %v exceeds reservedConcurrency of %v", minInstances, reserved)
+		}
+	}
+}
+
+func (v *validator) validateStages(document *yaml.Node, stages *yaml.Node, functions *yaml.Node) {
+	if stages == nil {
+		if v.stageName != "" {
+			v.addError(document, "unknown stage %v, the deployment file does not define any stages", v.stageName)
+		}
+		return
+	}
+	if stages.Kind != yaml.SequenceNode {
+		v.addError(stages, "stages must be a list")
+		return
+	}
+
+	//Settings of a stage apply to every provider used by the functions
+	var providerNames []ProviderName
+	if functions != nil {
+		for _, function := range functions.Content {
+			providers := GetValue(function, "providers")
+			if providers == nil {
+				continue
+			}
+			for _, provider := range providers.Content {
+				if nameNode := GetValue(provider, "name"); nameNode != nil && !Contains(providerNames, ProviderName(nameNode.Value)) {
+					providerNames = append(providerNames, ProviderName(nameNode.Value))
+				}
+			}
+		}
+	}
+
+	stageLines := make(map[string]int)
+	for _, stage := range stages.Content {
+		if !v.checkMapping(stage, "stage", stageKeys) {
+			continue
+		}
+		nameNode := GetValue(stage, "name")
+		name := v.checkString(nameNode, "stage name")
+		if line, exists := stageLines[name]; exists && name != "" {
+			v.addError(nameNode, "stage %v is already defined in line %v", name, line)
+		} else if name != "" {
+			stageLines[name] = nameNode.Line
+		}
+		if name == v.stageName && v.stage == nil {
+			v.stage = stage
+		}
+
+		v.checkString(GetValue(stage, "suffix"), "suffix")
+		v.overrideSettings(targetSettings{}, stage)
+		for _, provider := range providerNames {
+			if limits, ok := getProviderLimits(provider); ok {
+				v.checkLimits(GetValue(stage, "memory"), GetValue(stage, "timeout"), provider, limits)
+			}
+		}
+		v.validateEnvironment(stage, providerNames)
+		v.validateStageProviders(stage)
+	}
+
+	if v.stageName != "" && v.stage == nil {
+		v.addError(stages, "unknown stage %v, defined stages are %v", v.stageName, sortedKeys(stageLines))
+	}
+}
+
+//Checks the regions and credentials files a stage defines per provider
+func (v *validator) validateStageProviders(stage *yaml.Node) {
+	for _, key := range []string{"regions", "credentials"} {
+		providers := GetValue(stage, key)
+		if providers == nil {
+			continue
+		}
+		if providers.Kind != yaml.MappingNode {
+			v.addError(providers, "%v of a stage must be a mapping of provider names", key)
+			continue
+		}
+
+		for i := 0; i+1 < len(providers.Content); i += 2 {
+			nameNode, valueNode := providers.Content[i], providers.Content[i+1]
+			limits, ok := getProviderLimits(ProviderName(nameNode.Value))
+			if !ok {
+				v.addError(nameNode, "unknown provider %v, valid values are %v", nameNode.Value, ValidProviders())
+				continue
+			}
+			if key == "credentials" {
+				v.checkString(valueNode, "credentials file")
+				continue
+			}
+
+			if valueNode.Kind != yaml.SequenceNode || len(valueNode.Content) == 0 {
+				v.addError(valueNode, "regions of provider %v must be a non empty list", nameNode.Value)
+				continue
+			}
+			for _, regionNode := range valueNode.Content {
+				if regionNode.Kind == yaml.MappingNode {
+					if !v.checkMapping(regionNode, "region", regionKeys) {
+						continue
+					}
+					v.overrideSettings(targetSettings{}, regionNode)
+					if regionNode = GetValue(regionNode, "name"); regionNode == nil {
+						continue
+					}
+				}
+				if region := v.checkString(regionNode, "region"); region != "" && len(limits.Regions) > 0 && !Contains(limits.Regions, region) {
+					v.addError(regionNode, "unknown region %v for provider %v", region, nameNode.Value)
+				}
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Checks the environment variables and secrets of a function or provider section against the given providers
+func (v *validator) validateEnvironment(node *yaml.Node, providers []ProviderName) {
+	for _, key := range []string{"environment", "secrets"} {
+		variables := GetValue(node, key)
+		if variables == nil {
+			continue
+		}
+		if variables.Kind != yaml.MappingNode {
+			v.addError(variables, "%v must be a mapping", key)
+			continue
+		}
+		for i := 0; i+1 < len(variables.Content); i += 2 {
+			nameNode, valueNode := variables.Content[i], variables.Content[i+1]
+			if !environmentVariablePattern.MatchString(nameNode.Value) {
+				v.addError(nameNode, "invalid environment variable name %v, names may only contain letters, digits and underscores", nameNode.Value)
+			}
+			for _, provider := range providers {
+				if IsReservedVariable(provider, nameNode.Value) {
+					v.addError(nameNode, "environment variable %v is reserved by %v", nameNode.Value, provider)
+				}
+			}
+			if valueNode.Kind != yaml.ScalarNode {
+				v.addError(valueNode, "value of %v %v must be a string", key, nameNode.Value)
+			} else if key == "secrets" && valueNode.Value == "" {
+				v.addError(valueNode, "secret %v must name a local environment variable", nameNode.Value)
+			}
+		}
+	}
+}
+
+//Checks the triggers of a function or provider section against the given providers
+func (v *validator) validateTriggers(node *yaml.Node, section string, providers []ProviderName) {
+	triggers := GetValue(node, "triggers")
+	if triggers == nil {
+		return
+	}
+	if triggers.Kind != yaml.SequenceNode {
+		v.addError(triggers, "triggers must be a list")
+		return
+	}
+
+	http := 0
+	for _, trigger := range triggers.Content {
+		if !v.checkMapping(trigger, "trigger", triggerKeys) {
+			continue
+		}
+		typeNode := GetValue(trigger, "type")
+		triggerType := TriggerType(v.checkString(typeNode, "trigger type"))
+		if triggerType == "" {
+			continue
+		}
+		if !Contains(TriggerTypes, triggerType) {
+			v.addError(typeNode, "unknown trigger type %v, valid values are %v", triggerType, TriggerTypes)
+			continue
+		}
+		if triggerType == TriggerHTTP {
+			if http++; http == 2 {
+				v.addError(trigger, "only one http trigger can be used")
+			}
+		}
+		for _, provider := range providers {
+			if limits, ok := getProviderLimits(provider); ok && len(limits.TriggerTypes) > 0 && !Contains(limits.TriggerTypes, triggerType) {
+				v.addError(typeNode, "%v only supports %v triggers", provider, strings.Join(Map(limits.TriggerTypes, func(t TriggerType) string { return string(t) }), "|"))
+			}
+		}
+
+		for i := 0; i+1 < len(trigger.Content); i += 2 {
+			key := trigger.Content[i].Value
+			if key == "type" || key == string(triggerType) || (key == "public" && triggerType == TriggerHTTP) || (key == "events" && triggerType == TriggerBucket) {
+				continue
+			}
+			if _, ok := triggerKeys[key]; ok {
+				v.addError(trigger.Content[i], "key %v can not be used with %v triggers", key, triggerType)
+			}
+		}
+		if triggerType != TriggerHTTP && GetValue(trigger, string(triggerType)) == nil {
+			v.addError(trigger, "missing key %v in %v trigger", triggerType, triggerType)
+		}
+
+		if public := GetValue(trigger, "public"); public != nil && (public.Kind != yaml.ScalarNode || public.ShortTag() != "!!bool") {
+			v.addError(public, "public must be true or false")
+		}
+		if schedule := v.checkString(GetValue(trigger, "schedule"), "schedule"); schedule != "" {
+			if err := CheckSchedule(schedule); err != nil {
+				v.addError(GetValue(trigger, "schedule"), "%v", err)
+			}
+		}
+		for _, key := range []string{"queue", "topic", "bucket"} {
+			v.checkString(GetValue(trigger, key), key)
+		}
+		v.validateBucketEvents(GetValue(trigger, "events"))
+	}
+	v.validateWithProviders(section, "triggers", triggers, providers)
+}
+
+func (v *validator) validateBucketEvents(events *yaml.Node) {
+	if events == nil {
+		return
+	}
+	if events.Kind != yaml.SequenceNode || len(events.Content) == 0 {
+		v.addError(events, "events must be a non empty list")
+		return
+	}
+	for _, event := range events.Content {
+		if value := v.checkString(event, "event"); value != "" && value != BucketEventCreated && value != BucketEventDeleted {
+			v.addError(event, "unknown event %v, valid values are %v|%v", value, BucketEventCreated, BucketEventDeleted)
+		}
+	}
+}
+
+//Checks the tags of a function or provider section, the limits of the tags are checked by the given providers
+func (v *validator) validateTags(node *yaml.Node, section string, providers []ProviderName) {
+	tags := GetValue(node, "tags")
+	if tags == nil {
+		return
+	}
+	if tags.Kind != yaml.MappingNode {
+		v.addError(tags, "tags must be a mapping")
+		return
+	}
+
+	for i := 0; i+1 < len(tags.Content); i += 2 {
+		keyNode, valueNode := tags.Content[i], tags.Content[i+1]
+		key := keyNode.Value
+		if valueNode.Kind != yaml.ScalarNode {
+			v.addError(valueNode, "value of tag %v must be a string", key)
+			continue
+		}
+		if key == "" {
+			v.addError(keyNode, "tag keys must not be empty")
+			continue
+		}
+		if key == ManagedByTag {
+			v.addError(keyNode, "tag %v is set by GoDeploy", ManagedByTag)
+		}
+	}
+	v.validateWithProviders(section, "tags", tags, providers)
+}
+
+//Passes a setting to the validation of the given providers, unknown providers are reported elsewhere
+func (v *validator) validateWithProviders(section string, key string, node *yaml.Node, providers []ProviderName) {
+	for _, name := range providers {
+		if provider, ok := GetProvider(name); ok {
+			provider.Validate(section, key, node, v.addError)
+		}
+	}
+}
+
+//Checks that the network only uses settings of its provider and that subnets and security groups are given together
+func (v *validator) validateNetwork(network *yaml.Node, provider ProviderName) {
+	if network == nil || !v.checkMapping(network, "network", networkKeys) {
+		return
+	}
+	limits, _ := getProviderLimits(provider)
+	for i := 0; i+1 < len(network.Content); i += 2 {
+		key := network.Content[i]
+		if _, ok := networkKeys[key.Value]; ok && provider != "" && !Contains(limits.NetworkKeys, key.Value) {
+			v.addError(key, "network setting %v is not supported by %v", key.Value, provider)
+		}
+	}
+
+	subnets := v.checkIDs(GetValue(network, "subnets"), "subnets", "subnet-")
+	securityGroups := v.checkIDs(GetValue(network, "securityGroups"), "securityGroups", "sg-")
+	if subnets > 0 && securityGroups == 0 {
+		v.addError(network, "network with subnets needs at least one security group")
+	} else if subnets == 0 && securityGroups > 0 {
+		v.addError(network, "network with security groups needs at least one subnet")
+	}
+
+	connector := v.checkString(GetValue(network, "vpcConnector"), "vpcConnector")
+	if egress := GetValue(network, "egress"); egress != nil {
+		if value := v.checkString(egress, "egress"); value != "" && !Contains(EgressSettings, value) {
+			v.addError(egress, "unknown egress setting %v, valid values are %v", value, strings.Join(EgressSettings, "|"))
+		}
+		if connector == "" {
+			v.addError(egress, "egress can only be set together with vpcConnector")
+		}
+	}
+	if ingress := GetValue(network, "ingress"); ingress != nil {
+		if value := v.checkString(ingress, "ingress"); value != "" && !Contains(IngressSettings, value) {
+			v.addError(ingress, "unknown ingress setting %v, valid values are %v", value, strings.Join(IngressSettings, "|"))
+		}
+	}
+}
+
+//Checks that the node is a list of IDs with the given prefix and returns the number of IDs
+func (v *validator) checkIDs(node *yaml.Node, key string, prefix string) int {
+	if node == nil {
+		return 0
+	}
+	if node.Kind != yaml.SequenceNode {
+		v.addError(node, "%v must be a list of IDs", key)
+		return 0
+	}
+	for _, id := range node.Content {
+		if value := v.checkString(id, key+" ID"); value != "" && !strings.HasPrefix(value, prefix) {
+			v.addError(id, "invalid ID %v in %v, IDs have to start with %v", value, key, prefix)
+		}
+	}
+	return len(node.Content)
+}
+
+//Whether the environment variable is set by the provider and can not be used by functions
+func IsReservedVariable(provider ProviderName, name string) bool {
+	limits, _ := getProviderLimits(provider)
+	return Any(limits.ReservedEnvironment, func(reserved string) bool {
+		return name == reserved || (strings.HasSuffix(reserved, "_") && strings.HasPrefix(name, reserved))
+	})
+}
+
+func (v *validator) checkLimits(memoryNode *yaml.Node, timeoutNode *yaml.Node, provider ProviderName, limits ProviderLimits) {
+	if memory, err := numberValue(memoryNode); err == nil {
+		if len(limits.MemorySizes) > 0 && !Contains(limits.MemorySizes, int32(memory)) {
+			v.addError(memoryNode, "memory of %v MB is not supported by %v, supported sizes are %v", memory, provider, limits.MemorySizes)
+		} else if len(limits.MemorySizes) == 0 && (int32(memory) < limits.MinMemory || int32(memory) > limits.MaxMemory) {
+			v.addError(memoryNode, "memory of %v MB is not supported by %v, it must be between %v and %v MB", memory, provider, limits.MinMemory, limits.MaxMemory)
+		}
+	}
+	if timeout, err := numberValue(timeoutNode); err == nil {
+		if int32(timeout) < limits.MinTimeout || int32(timeout) > limits.MaxTimeout {
+			v.addError(timeoutNode, "timeout of %v seconds is not supported by %v, it must be between %v and %v seconds", timeout, provider, limits.MinTimeout, limits.MaxTimeout)
+		}
+	}
+}
+
+//Checks that the node is a mapping that only contains known keys and all required keys
+func (v *validator) checkMapping(node *yaml.Node, section string, keys map[string]bool) bool {
+	if node.Kind != yaml.MappingNode {
+		v.addError(node, "%v must be a mapping", section)
+		return false
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if _, ok := keys[key.Value]; !ok {
+			v.addError(key, "unknown key %v in %v", key.Value, section)
+		}
+	}
+	var requiredKeys []string
+	for key, required := range keys {
+		if required {
+			requiredKeys = append(requiredKeys, key)
+		}
+	}
+	sort.Strings(requiredKeys)
+	for _, key := range requiredKeys {
+		if GetValue(node, key) == nil {
+			v.addError(node, "missing key %v in %v", key, section)
+		}
+	}
+	return true
+}
+
+func (v *validator) checkString(node *yaml.Node, key string) string {
+	if node == nil {
+		return ""
+	}
+	if node.Kind != yaml.ScalarNode || node.Value == "" {
+		v.addError(node, "%v must be a non empty string", key)
+		return ""
+	}
+	return node.Value
+}
+
+func (v *validator) checkNumber(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if number, err := numberValue(node); err != nil || number <= 0 {
+		v.addError(node, "%v must be a positive number", key)
+		return nil
+	}
+	return node
+}
+
+func numberValue(node *yaml.Node) (int, error) {
+	if node == nil {
+		return 0, fmt.Errorf("missing value")
+	}
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+		return 0, fmt.Errorf("%v is not a number", node.Value)
+	}
+	return strconv.Atoi(node.Value)
+}
+
+//Returns the value of the given key of a mapping node, or nil if it does not exist
+func GetValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}